@@ -0,0 +1,281 @@
+//go:build wasm
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	"github.com/rohitsoni-dev/gocommander/cmd"
+)
+
+// commandCallbacks holds the JavaScript functions installed on a command and
+// the promises being awaited on its behalf
+type commandCallbacks struct {
+	handlers map[string]js.Value
+	hooks    map[cmd.HookEvent][]js.Value
+	pending  map[*pendingPromise]struct{}
+}
+
+// pendingPromise is a promise being awaited, with the js.Func handles passed
+// to its then method
+type pendingPromise struct {
+	onFulfilled js.Func
+	onRejected  js.Func
+	done        chan error
+}
+
+// release frees the js.Func handles of the promise
+func (p *pendingPromise) release() {
+	p.onFulfilled.Release()
+	p.onRejected.Release()
+}
+
+// callbackArg returns the function passed at args[index]
+func callbackArg(args []js.Value, index int) (js.Value, error) {
+	if len(args) <= index || args[index].Type() != js.TypeFunction {
+		return js.Value{}, fmt.Errorf("handler function is required")
+	}
+	return args[index], nil
+}
+
+// invokeCallback calls a JavaScript function, returning a thrown exception as
+// a Go error
+func invokeCallback(fn js.Value, args ...any) (result js.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			jsErr, ok := r.(js.Error)
+			if !ok {
+				panic(r)
+			}
+			err = jsError(jsErr.Value)
+		}
+	}()
+	return fn.Invoke(args...), nil
+}
+
+// awaitResult returns a channel receiving the outcome of a callback result:
+// the settlement of a promise, or nil at once for any other value
+func awaitResult(commandID string, result js.Value) <-chan error {
+	done := make(chan error, 1)
+	if result.Type() != js.TypeObject || result.Get("then").Type() != js.TypeFunction {
+		done <- nil
+		return done
+	}
+
+	pending := &pendingPromise{done: done}
+	pending.onFulfilled = js.FuncOf(func(this js.Value, args []js.Value) any {
+		registry.settlePending(commandID, pending, nil)
+		return nil
+	})
+	pending.onRejected = js.FuncOf(func(this js.Value, args []js.Value) any {
+		reason := js.Undefined()
+		if len(args) > 0 {
+			reason = args[0]
+		}
+		registry.settlePending(commandID, pending, jsError(reason))
+		return nil
+	})
+	registry.addPending(commandID, pending)
+
+	result.Call("then", pending.onFulfilled, pending.onRejected)
+	return done
+}
+
+// jsError converts a value thrown or rejected by JavaScript into a Go error,
+// keeping the Commander error type, code and exit code it carries
+func jsError(value js.Value) error {
+	if value.Type() != js.TypeObject {
+		return fmt.Errorf("%s", js.Global().Get("String").Invoke(value).String())
+	}
+
+	errorData := map[string]any{
+		"message": js.Global().Get("String").Invoke(value.Get("message")).String(),
+		"type":    "Error",
+	}
+	for _, key := range []string{"code", "command", "argument", "option", "value", "field", "token"} {
+		if field := value.Get(key); field.Type() == js.TypeString {
+			errorData[key] = field.String()
+		}
+	}
+	for _, key := range []string{"exitCode", "position"} {
+		if field := value.Get(key); field.Type() == js.TypeNumber {
+			errorData[key] = field.Int()
+		}
+	}
+
+	if name := value.Get("name"); name.Type() == js.TypeString && name.String() != "Error" {
+		errorData["type"] = name.String()
+	} else if _, hasCode := errorData["code"]; hasCode {
+		errorData["type"] = "CommanderError"
+	}
+	return DeserializeError(errorData)
+}
+
+// commandObject describes a command to a callback, including its ID when the
+// command is registered
+func commandObject(command *cmd.Command) map[string]any {
+	object := serializeCommand(command)
+	if id, exists := registry.IDOf(command); exists {
+		object["id"] = id
+	}
+	return object
+}
+
+// jsActionHandler calls fn as the command's action with the arguments, the
+// options and the command object. A returned promise is awaited, so the
+// action must run off the JavaScript event loop, as executeAction does.
+func jsActionHandler(commandID string, fn js.Value) cmd.ActionHandler {
+	return func(args []string, opts map[string]any) error {
+		return <-invokeAction(commandID, fn, args, opts)
+	}
+}
+
+// jsAsyncActionHandler calls fn as the command's async action, reporting the
+// settlement of the promise it returns on the channel
+func jsAsyncActionHandler(commandID string, fn js.Value) cmd.AsyncActionHandler {
+	return func(args []string, opts map[string]any) <-chan error {
+		return invokeAction(commandID, fn, args, opts)
+	}
+}
+
+// invokeAction converts the action arguments and calls fn with them
+func invokeAction(commandID string, fn js.Value, args []string, opts map[string]any) <-chan error {
+	done := make(chan error, 1)
+
+	actionArgs := make([]any, len(args))
+	for i, arg := range args {
+		actionArgs[i] = arg
+	}
+	jsArgs, err := globalTypeConverter.GoToJS(actionArgs)
+	if err != nil {
+		done <- fmt.Errorf("failed to convert action arguments: %v", err)
+		return done
+	}
+	jsOpts, err := globalTypeConverter.GoToJS(opts)
+	if err != nil {
+		done <- fmt.Errorf("failed to convert action options: %v", err)
+		return done
+	}
+	command, err := registry.Get(commandID)
+	if err != nil {
+		done <- err
+		return done
+	}
+	jsCommand, err := globalTypeConverter.GoToJS(commandObject(command))
+	if err != nil {
+		done <- fmt.Errorf("failed to convert command: %v", err)
+		return done
+	}
+
+	result, err := invokeCallback(fn, jsArgs, jsOpts, jsCommand)
+	if err != nil {
+		done <- err
+		return done
+	}
+	return awaitResult(commandID, result)
+}
+
+// jsHookHandler calls fn as a lifecycle hook with the objects of the command
+// the hook is on and of the command being run, awaiting a returned promise
+func jsHookHandler(commandID string, fn js.Value) cmd.HookHandler {
+	return func(thisCommand *cmd.Command, actionCommand *cmd.Command) error {
+		jsThis, err := globalTypeConverter.GoToJS(commandObject(thisCommand))
+		if err != nil {
+			return fmt.Errorf("failed to convert command: %v", err)
+		}
+		jsAction, err := globalTypeConverter.GoToJS(commandObject(actionCommand))
+		if err != nil {
+			return fmt.Errorf("failed to convert command: %v", err)
+		}
+
+		result, err := invokeCallback(fn, jsThis, jsAction)
+		if err != nil {
+			return err
+		}
+		return <-awaitResult(commandID, result)
+	}
+}
+
+// jsValueParser wraps fn as the parser of option or argument values. The
+// previous value is converted to JavaScript, undefined when there is none so
+// default parameters apply, and the result is converted back, so a parser
+// accumulating variadic values receives the array it returned last time.
+func jsValueParser(fn js.Value) func(value string, previous any) (any, error) {
+	return func(value string, previous any) (any, error) {
+		jsPrevious := js.Undefined()
+		if previous != nil {
+			converted, err := globalTypeConverter.GoToJS(previous)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert previous value: %v", err)
+			}
+			jsPrevious = converted
+		}
+
+		result, err := invokeCallback(fn, value, jsPrevious)
+		if err != nil {
+			return nil, err
+		}
+		return globalTypeConverter.JSToGo(result)
+	}
+}
+
+// callExitOverride reports err to the exit override of the command that
+// raised it in place of exiting, falling back to the overrides of its
+// ancestors up to root. A JavaScript override is called with the serialized
+// error, and the error it throws, if any, is returned, as Commander.js callers
+// throw from exitOverride to change how the program ends.
+func callExitOverride(root, command *cmd.Command, err error) error {
+	for current := command; current != nil; current = current.Parent {
+		if id, registered := registry.IDOf(current); registered {
+			if fn, exists := registry.Handler(id, "exitOverride"); exists {
+				jsErr, convertErr := globalTypeConverter.GoToJS(SerializeError(err))
+				if convertErr != nil {
+					return fmt.Errorf("failed to convert error: %v", convertErr)
+				}
+				_, thrown := invokeCallback(fn, jsErr)
+				return thrown
+			}
+		}
+		if current.ExitOverride != nil {
+			current.ExitOverride(err)
+			return nil
+		}
+		if current == root {
+			break
+		}
+	}
+	return nil
+}
+
+// errorCommand returns the command below root named by the full command name
+// err carries, or root when it names none
+func errorCommand(root *cmd.Command, err error) *cmd.Command {
+	var name string
+	var parseErr *cmd.ParseError
+	if errors.As(err, &parseErr) {
+		name = parseErr.Command
+	} else if cmdErr, ok := cmd.AsCommanderError(err); ok {
+		name = cmdErr.Command
+	}
+
+	var find func(command *cmd.Command) *cmd.Command
+	find = func(command *cmd.Command) *cmd.Command {
+		if command.GetFullName() == name {
+			return command
+		}
+		for _, sub := range command.Subcommands {
+			if found := find(sub); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	if name != "" {
+		if found := find(root); found != nil {
+			return found
+		}
+	}
+	return root
+}
@@ -635,6 +635,8 @@ func SerializeError(err error) map[string]any {
 		result["option"] = e.Option
 		result["value"] = e.Value
 		result["position"] = e.Position
+		result["code"] = e.Code
+		result["token"] = e.Token
 	}
 
 	return result
@@ -706,6 +708,12 @@ func DeserializeError(errorData map[string]any) error {
 		if position, ok := errorData["position"].(int); ok {
 			err.Position = position
 		}
+		if code, ok := errorData["code"].(string); ok {
+			err.Code = code
+		}
+		if token, ok := errorData["token"].(string); ok {
+			err.Token = token
+		}
 		return err
 
 	default:
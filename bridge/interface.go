@@ -3,6 +3,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"syscall/js"
@@ -10,11 +11,6 @@ import (
 	"github.com/rohitsoni-dev/gocommander/cmd"
 )
 
-var (
-	commands = make(map[string]*cmd.Command)
-	nextID   = 1
-)
-
 // WASMError represents an error that can be serialized to JavaScript
 type WASMError struct {
 	Code    string `json:"code"`
@@ -36,9 +32,10 @@ func main() {
 	// Export functions to JavaScript with enhanced error handling
 	js.Global().Set("gocommander", js.ValueOf(map[string]any{
 		// Core command operations
-		"createCommand":  wrapFunction(createCommand),
-		"destroyCommand": wrapFunction(destroyCommand),
-		"cloneCommand":   wrapFunction(cloneCommand),
+		"createCommand":     wrapFunction(createCommand),
+		"destroyCommand":    wrapFunction(destroyCommand),
+		"cloneCommand":      wrapFunction(cloneCommand),
+		"defineCommandTree": wrapFunction(defineCommandTree),
 
 		// Option management
 		"addOption":          wrapFunction(addOption),
@@ -60,9 +57,10 @@ func main() {
 		"getOptionProcessingSummary":    wrapFunction(getOptionProcessingSummary),
 
 		// Argument management
-		"addArgument":    wrapFunction(addArgument),
-		"removeArgument": wrapFunction(removeArgument),
-		"getArgument":    wrapFunction(getArgument),
+		"addArgument":       wrapFunction(addArgument),
+		"removeArgument":    wrapFunction(removeArgument),
+		"getArgument":       wrapFunction(getArgument),
+		"setArgumentParser": wrapFunction(setArgumentParser),
 
 		// Subcommand management
 		"addSubcommand":           wrapFunction(addSubcommand),
@@ -77,6 +75,7 @@ func main() {
 		// Parsing and execution
 		"parseArguments":  wrapFunction(parseArguments),
 		"validateCommand": wrapFunction(validateCommand),
+		"run":             wrapAsyncFunction(run),
 
 		// Action and lifecycle
 		"setAction":        wrapFunction(setAction),
@@ -86,8 +85,8 @@ func main() {
 		"setPreSubcommand": wrapFunction(setPreSubcommand),
 		"addHook":          wrapFunction(addHook),
 		"removeHook":       wrapFunction(removeHook),
-		"executeAction":    wrapFunction(executeAction),
-		"executeHooks":     wrapFunction(executeHooks),
+		"executeAction":    wrapAsyncFunction(executeAction),
+		"executeHooks":     wrapAsyncFunction(executeHooks),
 		"getHookInfo":      wrapFunction(getHookInfo),
 
 		// Command information
@@ -138,46 +137,54 @@ func main() {
 // wrapFunction wraps a WASM function with standardized error handling and result formatting
 func wrapFunction(fn func([]js.Value) (any, error)) js.Func {
 	return js.FuncOf(func(this js.Value, args []js.Value) any {
-		result, err := fn(args)
+		return toWASMResult(fn(args))
+	})
+}
 
-		if err != nil {
-			// Use the enhanced error serialization
-			serializedError := SerializeError(err)
-
-			// Convert to WASMError format for consistency
-			wasmErr := &WASMError{
-				Code:    "COMMAND_ERROR",
-				Message: err.Error(),
-				Type:    "CommanderError",
-			}
+// wrapAsyncFunction wraps a WASM function that waits on JavaScript callbacks.
+// The function runs in its own goroutine, since blocking the event loop would
+// keep the promises it awaits from settling, and the returned Promise resolves
+// to its result.
+func wrapAsyncFunction(fn func([]js.Value) (any, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) any {
+		executor := js.FuncOf(func(this js.Value, promiseArgs []js.Value) any {
+			resolve := promiseArgs[0]
+			go func() {
+				resolve.Invoke(toWASMResult(fn(args)))
+			}()
+			return nil
+		})
+		defer executor.Release()
 
-			if serializedError != nil {
-				if code, ok := serializedError["code"].(string); ok {
-					wasmErr.Code = code
-				}
-				if errorType, ok := serializedError["type"].(string); ok {
-					wasmErr.Type = errorType
-				}
-			}
+		return js.Global().Get("Promise").New(executor)
+	})
+}
 
-			wasmResult := WASMResult{
-				Success: false,
-				Error:   wasmErr,
-			}
+// toWASMResult formats the result of a WASM function as a WASMResult
+func toWASMResult(result any, err error) js.Value {
+	if err != nil {
+		// Use the enhanced error serialization
+		serializedError := SerializeError(err)
+
+		// Convert to WASMError format for consistency
+		wasmErr := &WASMError{
+			Code:    "COMMAND_ERROR",
+			Message: err.Error(),
+			Type:    "CommanderError",
+		}
 
-			// Convert result to JS using type converter
-			jsResult, convertErr := globalTypeConverter.GoToJS(wasmResult)
-			if convertErr != nil {
-				// Fallback to basic conversion
-				return js.ValueOf(wasmResult)
+		if serializedError != nil {
+			if code, ok := serializedError["code"].(string); ok {
+				wasmErr.Code = code
+			}
+			if errorType, ok := serializedError["type"].(string); ok {
+				wasmErr.Type = errorType
 			}
-
-			return jsResult
 		}
 
 		wasmResult := WASMResult{
-			Success: true,
-			Data:    result,
+			Success: false,
+			Error:   wasmErr,
 		}
 
 		// Convert result to JS using type converter
@@ -188,7 +195,21 @@ func wrapFunction(fn func([]js.Value) (any, error)) js.Func {
 		}
 
 		return jsResult
-	})
+	}
+
+	wasmResult := WASMResult{
+		Success: true,
+		Data:    result,
+	}
+
+	// Convert result to JS using type converter
+	jsResult, convertErr := globalTypeConverter.GoToJS(wasmResult)
+	if convertErr != nil {
+		// Fallback to basic conversion
+		return js.ValueOf(wasmResult)
+	}
+
+	return jsResult
 }
 
 // createCommand creates a new command and returns its ID
@@ -206,8 +227,7 @@ func createCommand(args []js.Value) (any, error) {
 	command := cmd.NewCommand(name)
 	command.Description = description
 
-	id := generateID()
-	commands[id] = command
+	id := registry.Register(command)
 
 	return map[string]any{
 		"id":          id,
@@ -224,15 +244,16 @@ func destroyCommand(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	if _, exists := commands[commandID]; !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	// Registered subcommands go with the command
+	removed, err := registry.Remove(commandID)
+	if err != nil {
+		return nil, err
 	}
 
-	delete(commands, commandID)
-
 	return map[string]any{
 		"destroyed": true,
 		"id":        commandID,
+		"removed":   removed,
 	}, nil
 }
 
@@ -248,9 +269,9 @@ func cloneCommand(args []js.Value) (any, error) {
 		newName = args[1].String()
 	}
 
-	sourceCommand, exists := commands[sourceID]
-	if !exists {
-		return nil, fmt.Errorf("source command not found: %s", sourceID)
+	sourceCommand, err := registry.Get(sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("source %w", err)
 	}
 
 	// Create a deep copy of the command
@@ -259,8 +280,7 @@ func cloneCommand(args []js.Value) (any, error) {
 		clonedCommand.Name = newName
 	}
 
-	newID := generateID()
-	commands[newID] = clonedCommand
+	newID := registry.Register(clonedCommand)
 
 	return map[string]any{
 		"id":       newID,
@@ -279,9 +299,9 @@ func addOption(args []js.Value) (any, error) {
 	flags := args[1].String()
 	description := args[2].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	option := cmd.NewOption(flags, description)
@@ -314,9 +334,9 @@ func removeOption(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	optionFlag := args[1].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Find and remove the option
@@ -343,9 +363,9 @@ func getOption(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	optionFlag := args[1].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	for _, option := range command.Options {
@@ -367,9 +387,9 @@ func addArgument(args []js.Value) (any, error) {
 	name := args[1].String()
 	description := args[2].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	argument := cmd.NewArgument(name, description)
@@ -402,9 +422,9 @@ func removeArgument(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	argumentName := args[1].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Find and remove the argument
@@ -431,9 +451,9 @@ func getArgument(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	argumentName := args[1].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	for _, arg := range command.Arguments {
@@ -454,14 +474,14 @@ func addSubcommand(args []js.Value) (any, error) {
 	parentID := args[0].String()
 	childID := args[1].String()
 
-	parent, exists := commands[parentID]
-	if !exists {
-		return nil, fmt.Errorf("parent command not found: %s", parentID)
+	parent, err := registry.Get(parentID)
+	if err != nil {
+		return nil, fmt.Errorf("parent %w", err)
 	}
 
-	child, exists := commands[childID]
-	if !exists {
-		return nil, fmt.Errorf("child command not found: %s", childID)
+	child, err := registry.Get(childID)
+	if err != nil {
+		return nil, fmt.Errorf("child %w", err)
 	}
 
 	parent.AddSubcommand(child)
@@ -482,9 +502,9 @@ func removeSubcommand(args []js.Value) (any, error) {
 	parentID := args[0].String()
 	subcommandName := args[1].String()
 
-	parent, exists := commands[parentID]
-	if !exists {
-		return nil, fmt.Errorf("parent command not found: %s", parentID)
+	parent, err := registry.Get(parentID)
+	if err != nil {
+		return nil, fmt.Errorf("parent %w", err)
 	}
 
 	// Find and remove the subcommand
@@ -513,9 +533,9 @@ func findSubcommand(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	subcommandName := args[1].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	subcommand := command.FindSubcommand(subcommandName)
@@ -524,13 +544,7 @@ func findSubcommand(args []js.Value) (any, error) {
 	}
 
 	// Find the ID of the subcommand
-	var subcommandID string
-	for id, cmd := range commands {
-		if cmd == subcommand {
-			subcommandID = id
-			break
-		}
-	}
+	subcommandID, _ := registry.IDOf(subcommand)
 
 	return map[string]any{
 		"id":   subcommandID,
@@ -548,9 +562,9 @@ func parseArguments(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	jsArgs := args[1]
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert JavaScript array to Go slice
@@ -584,12 +598,12 @@ func validateCommand(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
-	err := command.Validate()
+	err = command.Validate()
 	if err != nil {
 		return map[string]any{
 			"valid": false,
@@ -610,17 +624,18 @@ func setAction(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Store a placeholder action handler
-	// In a full implementation, this would store a reference to the JS function
-	command.SetAction(func(args []string, opts map[string]any) error {
-		// This would call back to JavaScript
-		return nil
-	})
+	fn, err := callbackArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	registry.SetHandler(commandID, "action", fn)
+	command.SetAction(jsActionHandler(commandID, fn))
 
 	return map[string]any{
 		"actionSet": true,
@@ -635,17 +650,19 @@ func setPreAction(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Store a placeholder pre-action handler
-	command.PreAction = func(thisCommand *cmd.Command, actionCommand *cmd.Command) error {
-		// This would call back to JavaScript
-		return nil
+	fn, err := callbackArg(args, 1)
+	if err != nil {
+		return nil, err
 	}
 
+	registry.SetHandler(commandID, "preAction", fn)
+	command.PreAction = jsHookHandler(commandID, fn)
+
 	return map[string]any{
 		"preActionSet": true,
 	}, nil
@@ -659,23 +676,52 @@ func setPostAction(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Store a placeholder post-action handler
-	command.PostAction = func(thisCommand *cmd.Command, actionCommand *cmd.Command) error {
-		// This would call back to JavaScript
-		return nil
+	fn, err := callbackArg(args, 1)
+	if err != nil {
+		return nil, err
 	}
 
+	registry.SetHandler(commandID, "postAction", fn)
+	command.PostAction = jsHookHandler(commandID, fn)
+
 	return map[string]any{
 		"postActionSet": true,
 	}, nil
 }
 
-// executeAction executes the action for a command
+// setPreSubcommand sets the pre-subcommand hook for a command
+func setPreSubcommand(args []js.Value) (any, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("commandId is required")
+	}
+
+	commandID := args[0].String()
+
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, err := callbackArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	registry.SetHandler(commandID, "preSubcommand", fn)
+	command.PreSubcommand = jsHookHandler(commandID, fn)
+
+	return map[string]any{
+		"preSubcommandSet": true,
+	}, nil
+}
+
+// executeAction executes the action for a command. JavaScript receives a
+// Promise of the result, since the action may await JavaScript callbacks.
 func executeAction(args []js.Value) (any, error) {
 	if len(args) < 3 {
 		return nil, fmt.Errorf("commandId, arguments, and options are required")
@@ -685,12 +731,12 @@ func executeAction(args []js.Value) (any, error) {
 	jsArgs := args[1]
 	jsOpts := args[2]
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
-	if command.Action == nil {
+	if command.Action == nil && command.AsyncAction == nil {
 		return nil, fmt.Errorf("no action handler set for command: %s", command.Name)
 	}
 
@@ -703,10 +749,15 @@ func executeAction(args []js.Value) (any, error) {
 	// Convert JavaScript options to Go map
 	optsMap := jsObjectToGoMap(jsOpts)
 
-	// Execute the action
-	err := command.Action(argSlice, optsMap)
+	// Execute the action, waiting for an async action to complete. Errors are
+	// returned as is, so errors thrown by JavaScript keep their type and code.
+	if command.AsyncAction != nil {
+		err = <-command.AsyncAction(argSlice, optsMap)
+	} else {
+		err = command.Action(argSlice, optsMap)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("action execution failed: %v", err)
+		return nil, err
 	}
 
 	return map[string]any{
@@ -714,6 +765,88 @@ func executeAction(args []js.Value) (any, error) {
 	}, nil
 }
 
+// run parses argv with a command and dispatches to the command it resolves
+// to, running hooks and actions as Execute does, and returns the outcome.
+// Errors, including help or version being displayed, are reported to the
+// exit override and described in the outcome rather than ending the module.
+func run(args []js.Value) (any, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("commandId and arguments are required")
+	}
+
+	commandID := args[0].String()
+	jsArgs := args[1]
+
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert JavaScript array to Go slice
+	argSlice := make([]string, jsArgs.Length())
+	for i := 0; i < jsArgs.Length(); i++ {
+		argSlice[i] = jsArgs.Index(i).String()
+	}
+
+	outcome := map[string]any{
+		"exitCode":         0,
+		"helpDisplayed":    false,
+		"versionDisplayed": false,
+		"error":            nil,
+	}
+
+	raising := command
+	parsed, err := command.NewParser().ParseCommand(command, argSlice)
+	if err == nil {
+		leaf := parsed.Command
+		if leaf == nil {
+			leaf = command
+		}
+		leafID, _ := registry.IDOf(leaf)
+
+		valueSources := make(map[string]any, len(parsed.ValueSources))
+		for key, source := range parsed.ValueSources {
+			valueSources[key] = string(source)
+		}
+
+		outcome["command"] = leaf.GetFullName()
+		outcome["commandId"] = leafID
+		outcome["options"] = parsed.Options
+		outcome["arguments"] = parsed.Arguments
+		outcome["unknown"] = parsed.Unknown
+		outcome["valueSources"] = valueSources
+
+		raising = leaf
+		err = command.Dispatch(context.Background(), parsed)
+	} else {
+		raising = errorCommand(command, err)
+	}
+	if err == nil {
+		return outcome, nil
+	}
+
+	if thrown := callExitOverride(command, raising, err); thrown != nil {
+		err = thrown
+	}
+
+	exitCode := 1
+	if cmdErr, ok := cmd.AsCommanderError(err); ok {
+		exitCode = cmdErr.ExitCode
+		switch cmdErr.Code {
+		case "commander.helpDisplayed", "commander.help":
+			outcome["helpDisplayed"] = true
+		case "commander.versionDisplayed":
+			outcome["versionDisplayed"] = true
+		}
+	}
+	outcome["exitCode"] = exitCode
+	if exitCode != 0 {
+		outcome["error"] = SerializeError(err)
+	}
+
+	return outcome, nil
+}
+
 // getCommandInfo returns information about a command
 func getCommandInfo(args []js.Value) (any, error) {
 	if len(args) < 1 {
@@ -722,9 +855,9 @@ func getCommandInfo(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	return serializeCommand(command), nil
@@ -738,9 +871,9 @@ func getCommandTree(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	return serializeCommandTree(command), nil
@@ -754,9 +887,9 @@ func getUsage(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	usage := generateUsage(command)
@@ -776,9 +909,9 @@ func getHelp(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	help := generateHelp(command)
@@ -799,9 +932,9 @@ func setCommandConfig(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	jsConfig := args[1]
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply configuration from JavaScript object
@@ -820,9 +953,9 @@ func getCommandConfig(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	return getCommandConfigMap(command), nil
@@ -837,12 +970,12 @@ func setVersion(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	version := args[1].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
-	command.Version = version
+	command.SetVersion(version)
 
 	return map[string]any{
 		"versionSet": true,
@@ -857,9 +990,9 @@ func getVersion(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	return map[string]any{
@@ -871,7 +1004,7 @@ func getVersion(args []js.Value) (any, error) {
 func getAllCommands(args []js.Value) (any, error) {
 	result := make(map[string]any)
 
-	for id, command := range commands {
+	for id, command := range registry.All() {
 		result[id] = map[string]any{
 			"name":        command.Name,
 			"description": command.Description,
@@ -882,9 +1015,21 @@ func getAllCommands(args []js.Value) (any, error) {
 	return result, nil
 }
 
+// clearAllCommands removes the program of the given command, or every
+// program when no command ID is passed
 func clearAllCommands(args []js.Value) (any, error) {
-	commands = make(map[string]*cmd.Command)
-	nextID = 1
+	if len(args) > 0 && args[0].Type() == js.TypeString {
+		removed, err := registry.Clear(args[0].String())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"cleared": true,
+			"removed": removed,
+		}, nil
+	}
+
+	registry.ClearAll()
 
 	return map[string]any{
 		"cleared": true,
@@ -893,12 +1038,6 @@ func clearAllCommands(args []js.Value) (any, error) {
 
 // Helper functions
 
-func generateID() string {
-	id := fmt.Sprintf("cmd_%d", nextID)
-	nextID++
-	return id
-}
-
 func jsValueToGo(val js.Value) any {
 	result, err := globalTypeConverter.JSToGo(val)
 	if err != nil {
@@ -1095,18 +1234,6 @@ func cloneArgument(source *cmd.Argument) *cmd.Argument {
 	return clone
 }
 
-func getSubcommandInfo(result *cmd.ParseResult) map[string]any {
-	if result.Command == nil {
-		return nil
-	}
-
-	return map[string]any{
-		"name":        result.Command.Name,
-		"description": result.Command.Description,
-		"fullName":    result.Command.GetFullName(),
-	}
-}
-
 func getSubcommandInfoFromParsed(result *cmd.ParsedCommand) map[string]any {
 	if result.Command == nil {
 		return nil
@@ -1120,74 +1247,11 @@ func getSubcommandInfoFromParsed(result *cmd.ParsedCommand) map[string]any {
 }
 
 func generateUsage(command *cmd.Command) string {
-	usage := command.GetFullName()
-
-	// Add options placeholder
-	if len(command.Options) > 0 {
-		usage += " [options]"
-	}
-
-	// Add arguments
-	for _, arg := range command.Arguments {
-		if arg.Required {
-			if arg.Variadic {
-				usage += fmt.Sprintf(" <%s...>", arg.Name)
-			} else {
-				usage += fmt.Sprintf(" <%s>", arg.Name)
-			}
-		} else {
-			if arg.Variadic {
-				usage += fmt.Sprintf(" [%s...]", arg.Name)
-			} else {
-				usage += fmt.Sprintf(" [%s]", arg.Name)
-			}
-		}
-	}
-
-	// Add subcommands placeholder
-	if len(command.Subcommands) > 0 {
-		usage += " [command]"
-	}
-
-	return usage
+	return command.CreateHelp().CommandUsage(command)
 }
 
 func generateHelp(command *cmd.Command) string {
-	help := fmt.Sprintf("Usage: %s\n", generateUsage(command))
-
-	if command.Description != "" {
-		help += fmt.Sprintf("\n%s\n", command.Description)
-	}
-
-	// Add options help
-	if len(command.Options) > 0 {
-		help += "\nOptions:\n"
-		for _, opt := range command.Options {
-			if !opt.Hidden {
-				help += fmt.Sprintf("  %s  %s\n", opt.Flags, opt.Description)
-			}
-		}
-	}
-
-	// Add arguments help
-	if len(command.Arguments) > 0 {
-		help += "\nArguments:\n"
-		for _, arg := range command.Arguments {
-			help += fmt.Sprintf("  %s  %s\n", arg.Name, arg.Description)
-		}
-	}
-
-	// Add subcommands help
-	if len(command.Subcommands) > 0 {
-		help += "\nCommands:\n"
-		for _, sub := range command.Subcommands {
-			if !sub.Hidden {
-				help += fmt.Sprintf("  %s  %s\n", sub.Name, sub.Description)
-			}
-		}
-	}
-
-	return help
+	return command.GenerateHelp()
 }
 
 func getOptionTypeString(optType cmd.OptionType) string {
@@ -1200,6 +1264,8 @@ func getOptionTypeString(optType cmd.OptionType) string {
 		return "number"
 	case cmd.OptionTypeVariadic:
 		return "variadic"
+	case cmd.OptionTypeCount:
+		return "count"
 	default:
 		return "unknown"
 	}
@@ -1272,9 +1338,9 @@ func addBooleanOption(args []js.Value) (any, error) {
 	flags := args[1].String()
 	description := args[2].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	option := cmd.NewBooleanOption(flags, description)
@@ -1304,9 +1370,9 @@ func addVariadicOption(args []js.Value) (any, error) {
 	flags := args[1].String()
 	description := args[2].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	option := cmd.NewVariadicOption(flags, description)
@@ -1330,9 +1396,9 @@ func addNegatableOption(args []js.Value) (any, error) {
 	flags := args[1].String()
 	description := args[2].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	option := cmd.CreateNegatableOption(flags, description)
@@ -1356,9 +1422,9 @@ func addRequiredOption(args []js.Value) (any, error) {
 	flags := args[1].String()
 	description := args[2].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	option := cmd.CreateRequiredOption(flags, description)
@@ -1387,9 +1453,9 @@ func setOptionParser(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	optionFlag := args[1].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Find the option
@@ -1405,9 +1471,13 @@ func setOptionParser(args []js.Value) (any, error) {
 		return nil, fmt.Errorf("option not found: %s", optionFlag)
 	}
 
-	// For now, just acknowledge that a parser was set
-	// In a full implementation, this would store a reference to the JS function
-	// and create a Go wrapper that calls back to JavaScript
+	fn, err := callbackArg(args, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	registry.SetHandler(commandID, "optionParser:"+targetOption.Flags, fn)
+	targetOption.SetParser(jsValueParser(fn))
 
 	return map[string]any{
 		"parserSet": true,
@@ -1415,6 +1485,47 @@ func setOptionParser(args []js.Value) (any, error) {
 	}, nil
 }
 
+// setArgumentParser sets a custom parser for an argument
+func setArgumentParser(args []js.Value) (any, error) {
+	if len(args) < 2 {
+		return nil, fmt.Errorf("commandId and argumentName are required")
+	}
+
+	commandID := args[0].String()
+	argumentName := args[1].String()
+
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the argument
+	var targetArgument *cmd.Argument
+	for _, argument := range command.Arguments {
+		if argument.Name == argumentName {
+			targetArgument = argument
+			break
+		}
+	}
+
+	if targetArgument == nil {
+		return nil, fmt.Errorf("argument not found: %s", argumentName)
+	}
+
+	fn, err := callbackArg(args, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	registry.SetHandler(commandID, "argumentParser:"+argumentName, fn)
+	targetArgument.SetParser(jsValueParser(fn))
+
+	return map[string]any{
+		"parserSet": true,
+		"argument":  argumentName,
+	}, nil
+}
+
 // setOptionChoices sets choices for an option
 func setOptionChoices(args []js.Value) (any, error) {
 	if len(args) < 3 {
@@ -1425,9 +1536,9 @@ func setOptionChoices(args []js.Value) (any, error) {
 	optionFlag := args[1].String()
 	jsChoices := args[2]
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Find the option
@@ -1472,9 +1583,9 @@ func setOptionEnv(args []js.Value) (any, error) {
 	optionFlag := args[1].String()
 	envVar := args[2].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Find the option
@@ -1509,9 +1620,9 @@ func setOptionConflicts(args []js.Value) (any, error) {
 	optionFlag := args[1].String()
 	jsConflicts := args[2]
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Find the option
@@ -1556,9 +1667,9 @@ func setOptionImplies(args []js.Value) (any, error) {
 	optionFlag := args[1].String()
 	jsImplies := args[2]
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Find the option
@@ -1574,18 +1685,11 @@ func setOptionImplies(args []js.Value) (any, error) {
 		return nil, fmt.Errorf("option not found: %s", optionFlag)
 	}
 
-	// Convert JavaScript array to Go slice
-	if !jsImplies.InstanceOf(js.Global().Get("Array")) {
-		return nil, fmt.Errorf("implies must be an array")
-	}
-
-	implies := make([]string, jsImplies.Length())
-	for i := 0; i < jsImplies.Length(); i++ {
-		implies[i] = jsImplies.Index(i).String()
+	implies, err := applyImplies(targetOption, jsImplies)
+	if err != nil {
+		return nil, err
 	}
 
-	targetOption.SetImplies(implies)
-
 	return map[string]any{
 		"impliesSet": true,
 		"option":     optionFlag,
@@ -1593,6 +1697,34 @@ func setOptionImplies(args []js.Value) (any, error) {
 	}, nil
 }
 
+// applyImplies sets what an option implies: an array names options implied
+// as true, while a plain object maps option names to the values they take,
+// as with Commander.js .implies({key: value})
+func applyImplies(option *cmd.Option, jsImplies js.Value) (any, error) {
+	if jsImplies.InstanceOf(js.Global().Get("Array")) {
+		implies := make([]string, jsImplies.Length())
+		for i := range implies {
+			implies[i] = jsImplies.Index(i).String()
+		}
+		option.SetImplies(implies)
+		return implies, nil
+	}
+
+	if jsImplies.Type() != js.TypeObject || jsImplies.IsNull() {
+		return nil, fmt.Errorf("implies must be an array or an object")
+	}
+	converted, err := globalTypeConverter.JSToGo(jsImplies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert implied values: %v", err)
+	}
+	values, ok := converted.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("implies must be an array or an object")
+	}
+	option.SetImpliedValues(values)
+	return values, nil
+}
+
 // processOptionWithEnhancements processes an option with enhanced features
 func processOptionWithEnhancements(args []js.Value) (any, error) {
 	if len(args) < 3 {
@@ -1603,9 +1735,9 @@ func processOptionWithEnhancements(args []js.Value) (any, error) {
 	flag := args[1].String()
 	value := args[2].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create enhanced option processor
@@ -1644,9 +1776,9 @@ func validateOptionGroups(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create enhanced option processor
@@ -1680,9 +1812,9 @@ func getOptionProcessingSummary(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create contextual option processor
@@ -1709,9 +1841,9 @@ func setExecutableSubcommand(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	executableFile := args[1].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Set executable configuration
@@ -1739,9 +1871,9 @@ func setDefaultSubcommand(args []js.Value) (any, error) {
 	parentID := args[0].String()
 	subcommandName := args[1].String()
 
-	parent, exists := commands[parentID]
-	if !exists {
-		return nil, fmt.Errorf("parent command not found: %s", parentID)
+	parent, err := registry.Get(parentID)
+	if err != nil {
+		return nil, fmt.Errorf("parent %w", err)
 	}
 
 	// Find the subcommand
@@ -1769,9 +1901,9 @@ func addCommandAlias(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	alias := args[1].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	command.AddAlias(alias)
@@ -1792,9 +1924,9 @@ func setCommandAliases(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	jsAliases := args[1]
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert JavaScript array to Go slice
@@ -1823,9 +1955,9 @@ func getSubcommandInfo(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	subcommands := make([]map[string]any, len(command.Subcommands))
@@ -1867,20 +1999,18 @@ func setAsyncAction(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Store a placeholder async action handler
-	command.SetAsyncAction(func(args []string, opts map[string]any) <-chan error {
-		errChan := make(chan error, 1)
-		// This would call back to JavaScript asynchronously
-		go func() {
-			errChan <- nil
-		}()
-		return errChan
-	})
+	fn, err := callbackArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	registry.SetHandler(commandID, "asyncAction", fn)
+	command.SetAsyncAction(jsAsyncActionHandler(commandID, fn))
 
 	return map[string]any{
 		"asyncActionSet": true,
@@ -1896,9 +2026,9 @@ func addHook(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	hookType := args[1].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert string to HookEvent
@@ -1914,13 +2044,13 @@ func addHook(args []js.Value) (any, error) {
 		return nil, fmt.Errorf("invalid hook type: %s", hookType)
 	}
 
-	// Add a placeholder hook handler
-	handler := func(thisCommand *cmd.Command, actionCommand *cmd.Command) error {
-		// This would call back to JavaScript
-		return nil
+	fn, err := callbackArg(args, 2)
+	if err != nil {
+		return nil, err
 	}
 
-	command.AddHook(event, handler)
+	registry.AddHook(commandID, event, fn)
+	command.AddHook(event, jsHookHandler(commandID, fn))
 
 	return map[string]any{
 		"hookAdded": true,
@@ -1938,9 +2068,9 @@ func removeHook(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	hookType := args[1].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert string to HookEvent
@@ -1957,6 +2087,7 @@ func removeHook(args []js.Value) (any, error) {
 	}
 
 	command.RemoveHook(event)
+	registry.RemoveHooks(commandID, event)
 
 	return map[string]any{
 		"hookRemoved": true,
@@ -1965,7 +2096,8 @@ func removeHook(args []js.Value) (any, error) {
 	}, nil
 }
 
-// executeHooks executes all hooks of a specific type
+// executeHooks executes all hooks of a specific type. JavaScript receives a
+// Promise of the result, since the hooks may await JavaScript callbacks.
 func executeHooks(args []js.Value) (any, error) {
 	if len(args) < 2 {
 		return nil, fmt.Errorf("commandId and hookType are required")
@@ -1974,9 +2106,9 @@ func executeHooks(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	hookType := args[1].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert string to HookEvent
@@ -1996,13 +2128,13 @@ func executeHooks(args []js.Value) (any, error) {
 	actionCommand := command
 	if len(args) > 2 && !args[2].IsUndefined() {
 		actionCommandID := args[2].String()
-		if actionCmd, exists := commands[actionCommandID]; exists {
+		if actionCmd, err := registry.Get(actionCommandID); err == nil {
 			actionCommand = actionCmd
 		}
 	}
 
 	// Execute the hooks
-	err := command.ExecuteHooks(event, actionCommand)
+	err = command.ExecuteHooks(event, actionCommand)
 	if err != nil {
 		return nil, err
 	}
@@ -2022,9 +2154,9 @@ func getHookInfo(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	return map[string]any{
@@ -2046,9 +2178,9 @@ func setParsingConfig(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	jsConfig := args[1]
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply parsing configuration from JavaScript object
@@ -2086,9 +2218,9 @@ func getParsingConfig(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	return map[string]any{
@@ -2110,9 +2242,9 @@ func setPositionalOption(args []js.Value) (any, error) {
 	position := int(args[1].Float())
 	optionName := args[2].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// For now, store this configuration in the command
@@ -2140,9 +2272,9 @@ func setUnknownOptionHandler(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// For now, just mark that a custom handler is set
@@ -2165,9 +2297,9 @@ func setExcessArgumentHandler(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// For now, just mark that a custom handler is set
@@ -2191,9 +2323,9 @@ func configureOutput(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	jsConfig := args[1]
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply output configuration from JavaScript object
@@ -2260,9 +2392,9 @@ func configureError(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	jsConfig := args[1]
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply error configuration from JavaScript object
@@ -2305,17 +2437,22 @@ func setExitOverride(args []js.Value) (any, error) {
 
 	commandID := args[0].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Set placeholder exit override handler
-	command.SetExitOverride(func(err error) {
-		// This would call back to JavaScript exit override function
-		// For now, just log the error
-		fmt.Fprintf(os.Stderr, "Exit override: %v\n", err)
-	})
+	if len(args) > 1 && args[1].Type() == js.TypeFunction {
+		registry.SetHandler(commandID, "exitOverride", args[1])
+		command.SetExitOverride(func(err error) {
+			callExitOverride(command, command, err)
+		})
+	} else {
+		// Without a handler, report the error instead of exiting
+		command.SetExitOverride(func(err error) {
+			fmt.Fprintf(os.Stderr, "Exit override: %v\n", err)
+		})
+	}
 
 	return map[string]any{
 		"exitOverrideSet": true,
@@ -2331,9 +2468,9 @@ func generateSuggestion(args []js.Value) (any, error) {
 	commandID := args[0].String()
 	unknownCommand := args[1].String()
 
-	command, exists := commands[commandID]
-	if !exists {
-		return nil, fmt.Errorf("command not found: %s", commandID)
+	command, err := registry.Get(commandID)
+	if err != nil {
+		return nil, err
 	}
 
 	suggestion := command.GenerateSuggestion(unknownCommand)
@@ -4,6 +4,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 	"syscall/js"
 	"testing"
 
@@ -12,8 +13,7 @@ import (
 
 func TestCreateCommand(t *testing.T) {
 	// Clear commands before test
-	commands = make(map[string]*cmd.Command)
-	nextID = 1
+	registry = NewRegistry()
 
 	tests := []struct {
 		name        string
@@ -67,8 +67,7 @@ func TestCreateCommand(t *testing.T) {
 
 func TestCommandLifecycle(t *testing.T) {
 	// Clear commands before test
-	commands = make(map[string]*cmd.Command)
-	nextID = 1
+	registry = NewRegistry()
 
 	// Create a command
 	result, err := createCommand([]js.Value{js.ValueOf("test")})
@@ -124,8 +123,7 @@ func TestCommandLifecycle(t *testing.T) {
 
 func TestOptionManagement(t *testing.T) {
 	// Clear commands before test
-	commands = make(map[string]*cmd.Command)
-	nextID = 1
+	registry = NewRegistry()
 
 	// Create a command
 	result, err := createCommand([]js.Value{js.ValueOf("test")})
@@ -220,10 +218,52 @@ func TestOptionManagement(t *testing.T) {
 	}
 }
 
+func TestOptionImplies(t *testing.T) {
+	registry = NewRegistry()
+
+	result, err := createCommand([]js.Value{js.ValueOf("test")})
+	if err != nil {
+		t.Fatalf("Failed to create command: %v", err)
+	}
+	commandID := result.(map[string]any)["id"].(string)
+	command, _ := registry.Get(commandID)
+	command.AddOption(cmd.NewBooleanOption("--quick", "quick run"))
+	command.AddOption(cmd.NewBooleanOption("--ci", "ci run"))
+	command.AddOption(cmd.NewBooleanOption("--smoke", "smoke tests only"))
+	command.AddOption(cmd.NewOption("--level <n>", "level"))
+
+	// Test that an object maps implied options to values
+	implied := js.ValueOf(map[string]any{"level": "fast", "smoke": true})
+	if _, err := setOptionImplies([]js.Value{js.ValueOf(commandID), js.ValueOf("quick"), implied}); err != nil {
+		t.Fatalf("Failed to set implied values: %v", err)
+	}
+	// Test that an array still names options implied as true
+	if _, err := setOptionImplies([]js.Value{js.ValueOf(commandID), js.ValueOf("ci"), js.ValueOf([]any{"smoke"})}); err != nil {
+		t.Fatalf("Failed to set implied options: %v", err)
+	}
+	if _, err := setOptionImplies([]js.Value{js.ValueOf(commandID), js.ValueOf("ci"), js.ValueOf("smoke")}); err == nil {
+		t.Error("Expected an error for implies that is neither an array nor an object")
+	}
+
+	parsed, err := cmd.NewParser().ParseCommand(command, []string{"--quick"})
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if parsed.Options["level"] != "fast" || parsed.Options["smoke"] != true {
+		t.Errorf("Expected implied level and smoke, got %v", parsed.Options)
+	}
+	parsed, err = cmd.NewParser().ParseCommand(command, []string{"--ci"})
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if parsed.Options["smoke"] != true {
+		t.Errorf("Expected implied smoke, got %v", parsed.Options)
+	}
+}
+
 func TestArgumentParsing(t *testing.T) {
 	// Clear commands before test
-	commands = make(map[string]*cmd.Command)
-	nextID = 1
+	registry = NewRegistry()
 
 	// Create a command with options and arguments
 	result, err := createCommand([]js.Value{js.ValueOf("test")})
@@ -318,8 +358,7 @@ func TestArgumentParsing(t *testing.T) {
 
 func TestSubcommandManagement(t *testing.T) {
 	// Clear commands before test
-	commands = make(map[string]*cmd.Command)
-	nextID = 1
+	registry = NewRegistry()
 
 	// Create parent command
 	parentResult, err := createCommand([]js.Value{js.ValueOf("parent")})
@@ -384,8 +423,7 @@ func TestSubcommandManagement(t *testing.T) {
 
 func TestCommandValidation(t *testing.T) {
 	// Clear commands before test
-	commands = make(map[string]*cmd.Command)
-	nextID = 1
+	registry = NewRegistry()
 
 	// Create a valid command
 	result, err := createCommand([]js.Value{js.ValueOf("test")})
@@ -419,8 +457,7 @@ func TestCommandValidation(t *testing.T) {
 
 func TestHookManagement(t *testing.T) {
 	// Clear commands before test
-	commands = make(map[string]*cmd.Command)
-	nextID = 1
+	registry = NewRegistry()
 
 	// Create a command
 	result, err := createCommand([]js.Value{js.ValueOf("test")})
@@ -432,17 +469,32 @@ func TestHookManagement(t *testing.T) {
 
 	// Test adding hooks
 	hookTypes := []string{"preAction", "postAction", "preSubcommand"}
+	calls := make(map[string]int)
 
 	for _, hookType := range hookTypes {
+		hookType := hookType
+		listener := js.FuncOf(func(this js.Value, args []js.Value) any {
+			calls[hookType]++
+			return nil
+		})
+		defer listener.Release()
+
 		_, err = addHook([]js.Value{
 			js.ValueOf(commandID),
 			js.ValueOf(hookType),
+			listener.Value,
 		})
 		if err != nil {
 			t.Errorf("Failed to add %s hook: %v", hookType, err)
 		}
 	}
 
+	// Test adding a hook without a listener
+	_, err = addHook([]js.Value{js.ValueOf(commandID), js.ValueOf("preAction")})
+	if err == nil {
+		t.Error("Expected error when adding a hook without a listener")
+	}
+
 	// Test getting hook info
 	hookInfo, err := getHookInfo([]js.Value{js.ValueOf(commandID)})
 	if err != nil {
@@ -463,6 +515,9 @@ func TestHookManagement(t *testing.T) {
 		if err != nil {
 			t.Errorf("Failed to execute %s hooks: %v", hookType, err)
 		}
+		if calls[hookType] != 1 {
+			t.Errorf("Expected %s hook to be called once, got %d", hookType, calls[hookType])
+		}
 	}
 
 	// Test removing hooks
@@ -477,10 +532,329 @@ func TestHookManagement(t *testing.T) {
 	}
 }
 
+func TestActionCallbacks(t *testing.T) {
+	registry = NewRegistry()
+
+	result, err := createCommand([]js.Value{js.ValueOf("test")})
+	if err != nil {
+		t.Fatalf("Failed to create command: %v", err)
+	}
+	commandID := result.(map[string]any)["id"].(string)
+
+	// Test that the action receives the arguments, options and command
+	var received []js.Value
+	action := js.FuncOf(func(this js.Value, args []js.Value) any {
+		received = args
+		return nil
+	})
+	defer action.Release()
+
+	if _, err := setAction([]js.Value{js.ValueOf(commandID), action.Value}); err != nil {
+		t.Fatalf("Failed to set action: %v", err)
+	}
+	_, err = executeAction([]js.Value{
+		js.ValueOf(commandID),
+		js.ValueOf([]any{"file.txt"}),
+		js.ValueOf(map[string]any{"verbose": true}),
+	})
+	if err != nil {
+		t.Fatalf("Failed to execute action: %v", err)
+	}
+	if len(received) != 3 {
+		t.Fatalf("Expected action to receive 3 arguments, got %d", len(received))
+	}
+	if got := received[0].Index(0).String(); got != "file.txt" {
+		t.Errorf("Expected argument 'file.txt', got %q", got)
+	}
+	if !received[1].Get("verbose").Bool() {
+		t.Error("Expected option 'verbose' to be true")
+	}
+	if got := received[2].Get("id").String(); got != commandID {
+		t.Errorf("Expected command id %q, got %q", commandID, got)
+	}
+
+	// Test that a thrown error keeps its code and exit code
+	throwing := js.Global().Get("Function").New("args", "opts", "command", `
+		const err = new Error("bad input");
+		err.code = "commander.custom";
+		err.exitCode = 3;
+		throw err;
+	`)
+	if _, err := setAction([]js.Value{js.ValueOf(commandID), throwing}); err != nil {
+		t.Fatalf("Failed to set action: %v", err)
+	}
+	_, err = executeAction([]js.Value{js.ValueOf(commandID), js.ValueOf([]any{}), js.ValueOf(map[string]any{})})
+	cmdErr, ok := cmd.AsCommanderError(err)
+	if !ok {
+		t.Fatalf("Expected CommanderError, got %v", err)
+	}
+	if cmdErr.Code != "commander.custom" || cmdErr.ExitCode != 3 || cmdErr.Message != "bad input" {
+		t.Errorf("Unexpected error: %+v", cmdErr)
+	}
+
+	// Test that an async action's rejected promise is reported
+	rejecting := js.Global().Get("Function").New("args", "opts", "command", `
+		const err = new Error("bad value");
+		err.name = "InvalidArgumentError";
+		err.argument = "file";
+		return Promise.reject(err);
+	`)
+	if _, err := setAsyncAction([]js.Value{js.ValueOf(commandID), rejecting}); err != nil {
+		t.Fatalf("Failed to set async action: %v", err)
+	}
+	_, err = executeAction([]js.Value{js.ValueOf(commandID), js.ValueOf([]any{}), js.ValueOf(map[string]any{})})
+	argErr, ok := err.(*cmd.InvalidArgumentError)
+	if !ok {
+		t.Fatalf("Expected InvalidArgumentError, got %T: %v", err, err)
+	}
+	if argErr.Argument != "file" || argErr.Message != "bad value" {
+		t.Errorf("Unexpected error: %+v", argErr)
+	}
+
+	// Test that actions require a function
+	if _, err := setAction([]js.Value{js.ValueOf(commandID)}); err == nil {
+		t.Error("Expected error when setting an action without a function")
+	}
+
+	// Test that destroying the command releases its callbacks
+	if _, err := destroyCommand([]js.Value{js.ValueOf(commandID)}); err != nil {
+		t.Fatalf("Failed to destroy command: %v", err)
+	}
+	if retained := registry.Stats()["retainedFunctions"]; retained != 0 {
+		t.Errorf("Expected callbacks to be released with the command, %v retained", retained)
+	}
+}
+
+func TestValueParsers(t *testing.T) {
+	registry = NewRegistry()
+
+	result, err := createCommand([]js.Value{js.ValueOf("test")})
+	if err != nil {
+		t.Fatalf("Failed to create command: %v", err)
+	}
+	commandID := result.(map[string]any)["id"].(string)
+
+	_, err = addOption([]js.Value{js.ValueOf(commandID), js.ValueOf("-p, --port <number>"), js.ValueOf("Port")})
+	if err != nil {
+		t.Fatalf("Failed to add option: %v", err)
+	}
+	_, err = addVariadicOption([]js.Value{js.ValueOf(commandID), js.ValueOf("-t, --tag <tags...>"), js.ValueOf("Tags")})
+	if err != nil {
+		t.Fatalf("Failed to add option: %v", err)
+	}
+	_, err = addArgument([]js.Value{js.ValueOf(commandID), js.ValueOf("files"), js.ValueOf("Files"), js.ValueOf(false), js.ValueOf(true)})
+	if err != nil {
+		t.Fatalf("Failed to add argument: %v", err)
+	}
+
+	parseInteger := js.Global().Get("Function").New("value", `
+		const parsed = parseInt(value, 10);
+		if (isNaN(parsed)) {
+			const err = new Error("Not a number.");
+			err.name = "InvalidArgumentError";
+			err.code = "commander.invalidArgument";
+			throw err;
+		}
+		return parsed;
+	`)
+	collect := js.Global().Get("Function").New("value", "previous = []", "return previous.concat([value.toUpperCase()]);")
+
+	if _, err := setOptionParser([]js.Value{js.ValueOf(commandID), js.ValueOf("port"), parseInteger}); err != nil {
+		t.Fatalf("Failed to set option parser: %v", err)
+	}
+	if _, err := setOptionParser([]js.Value{js.ValueOf(commandID), js.ValueOf("tag"), collect}); err != nil {
+		t.Fatalf("Failed to set option parser: %v", err)
+	}
+	if _, err := setArgumentParser([]js.Value{js.ValueOf(commandID), js.ValueOf("files"), collect}); err != nil {
+		t.Fatalf("Failed to set argument parser: %v", err)
+	}
+
+	// Test that parsed values are converted back to Go
+	result, err = parseArguments([]js.Value{
+		js.ValueOf(commandID),
+		js.ValueOf([]any{"--port", "8080", "--tag", "a", "b", "--tag", "c", "--", "x.txt", "y.txt"}),
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse arguments: %v", err)
+	}
+	parsed := result.(map[string]any)
+	options := parsed["options"].(map[string]any)
+	if options["port"] != int64(8080) {
+		t.Errorf("Expected port 8080, got %#v", options["port"])
+	}
+	if got := fmt.Sprint(options["tag"]); got != "[A B C]" {
+		t.Errorf("Expected accumulated tags [A B C], got %s", got)
+	}
+	if got := fmt.Sprint(parsed["arguments"]); got != "[[X.TXT Y.TXT]]" {
+		t.Errorf("Expected accumulated files [[X.TXT Y.TXT]], got %s", got)
+	}
+
+	// Test that an error thrown by the parser keeps its message
+	_, err = parseArguments([]js.Value{js.ValueOf(commandID), js.ValueOf([]any{"--port", "http"})})
+	if err == nil || !strings.Contains(err.Error(), "Not a number.") {
+		t.Errorf("Expected error with parser message, got %v", err)
+	}
+
+	// Test that parsers require a function
+	if _, err := setOptionParser([]js.Value{js.ValueOf(commandID), js.ValueOf("port")}); err == nil {
+		t.Error("Expected error when setting a parser without a function")
+	}
+	if _, err := setArgumentParser([]js.Value{js.ValueOf(commandID), js.ValueOf("missing"), collect}); err == nil {
+		t.Error("Expected error when setting a parser on an unknown argument")
+	}
+}
+
+func TestRun(t *testing.T) {
+	registry = NewRegistry()
+
+	result, err := createCommand([]js.Value{js.ValueOf("app")})
+	if err != nil {
+		t.Fatalf("Failed to create command: %v", err)
+	}
+	rootID := result.(map[string]any)["id"].(string)
+	result, err = createCommand([]js.Value{js.ValueOf("build")})
+	if err != nil {
+		t.Fatalf("Failed to create command: %v", err)
+	}
+	buildID := result.(map[string]any)["id"].(string)
+
+	if _, err := addSubcommand([]js.Value{js.ValueOf(rootID), js.ValueOf(buildID)}); err != nil {
+		t.Fatalf("Failed to add subcommand: %v", err)
+	}
+	_, err = addOption([]js.Value{js.ValueOf(buildID), js.ValueOf("--target <name>"), js.ValueOf("Target"), js.ValueOf("debug")})
+	if err != nil {
+		t.Fatalf("Failed to add option: %v", err)
+	}
+	_, err = addOption([]js.Value{js.ValueOf(buildID), js.ValueOf("--out <dir>"), js.ValueOf("Output"), js.ValueOf("dist")})
+	if err != nil {
+		t.Fatalf("Failed to add option: %v", err)
+	}
+
+	var events []string
+	preAction := js.FuncOf(func(this js.Value, args []js.Value) any {
+		events = append(events, "preAction:"+args[1].Get("id").String())
+		return nil
+	})
+	defer preAction.Release()
+	action := js.FuncOf(func(this js.Value, args []js.Value) any {
+		events = append(events, "action:"+args[1].Get("target").String())
+		return nil
+	})
+	defer action.Release()
+
+	if _, err := addHook([]js.Value{js.ValueOf(rootID), js.ValueOf("preAction"), preAction.Value}); err != nil {
+		t.Fatalf("Failed to add hook: %v", err)
+	}
+	if _, err := setAction([]js.Value{js.ValueOf(buildID), action.Value}); err != nil {
+		t.Fatalf("Failed to set action: %v", err)
+	}
+
+	// Test that run dispatches to the resolved subcommand
+	result, err = run([]js.Value{js.ValueOf(rootID), js.ValueOf([]any{"build", "--target", "release"})})
+	if err != nil {
+		t.Fatalf("Failed to run: %v", err)
+	}
+	outcome := result.(map[string]any)
+	if outcome["exitCode"] != 0 || outcome["error"] != nil {
+		t.Errorf("Expected success, got exit code %v and error %v", outcome["exitCode"], outcome["error"])
+	}
+	if outcome["commandId"] != buildID {
+		t.Errorf("Expected command id %q, got %v", buildID, outcome["commandId"])
+	}
+	if got := fmt.Sprint(events); got != "[preAction:"+buildID+" action:release]" {
+		t.Errorf("Unexpected events: %s", got)
+	}
+	valueSources := outcome["valueSources"].(map[string]any)
+	if valueSources["target"] != "cli" || valueSources["out"] != "default" {
+		t.Errorf("Unexpected value sources: %v", valueSources)
+	}
+
+	// Test that errors are described in the outcome
+	result, err = run([]js.Value{js.ValueOf(rootID), js.ValueOf([]any{"build", "--colr"})})
+	if err != nil {
+		t.Fatalf("Failed to run: %v", err)
+	}
+	outcome = result.(map[string]any)
+	if outcome["exitCode"] != 1 {
+		t.Errorf("Expected exit code 1, got %v", outcome["exitCode"])
+	}
+	if errorData, ok := outcome["error"].(map[string]any); !ok || errorData["code"] != "commander.unknownOption" {
+		t.Errorf("Expected unknown option error, got %v", outcome["error"])
+	}
+
+	// Test that help is reported rather than exiting
+	result, err = run([]js.Value{js.ValueOf(rootID), js.ValueOf([]any{"--help"})})
+	if err != nil {
+		t.Fatalf("Failed to run: %v", err)
+	}
+	outcome = result.(map[string]any)
+	if outcome["helpDisplayed"] != true || outcome["exitCode"] != 0 || outcome["error"] != nil {
+		t.Errorf("Expected help displayed with exit code 0, got %v", outcome)
+	}
+
+	// Test that an error thrown by the exit override replaces the error
+	var overridden []string
+	record := js.FuncOf(func(this js.Value, args []js.Value) any {
+		overridden = append(overridden, args[0].Get("code").String())
+		return nil
+	})
+	defer record.Release()
+	exitOverride := js.Global().Get("Function").New("record", `
+		return function(err) {
+			record(err);
+			const stop = new Error("stopped");
+			stop.code = "app.stopped";
+			stop.exitCode = 7;
+			throw stop;
+		};
+	`).Invoke(record)
+
+	if _, err := setExitOverride([]js.Value{js.ValueOf(rootID), exitOverride}); err != nil {
+		t.Fatalf("Failed to set exit override: %v", err)
+	}
+	result, err = run([]js.Value{js.ValueOf(rootID), js.ValueOf([]any{"build", "--colr"})})
+	if err != nil {
+		t.Fatalf("Failed to run: %v", err)
+	}
+	outcome = result.(map[string]any)
+	if got := fmt.Sprint(overridden); got != "[commander.unknownOption]" {
+		t.Errorf("Expected exit override to receive the unknown option error, got %s", got)
+	}
+	if errorData, ok := outcome["error"].(map[string]any); !ok || errorData["code"] != "app.stopped" || outcome["exitCode"] != 7 {
+		t.Errorf("Expected thrown error with exit code 7, got %v", outcome)
+	}
+
+	// Test that the override of the subcommand raising the error is used
+	buildOverride := js.Global().Get("Function").New(`
+		return function(err) {
+			const stop = new Error("build stopped");
+			stop.code = "build.stopped";
+			stop.exitCode = 9;
+			throw stop;
+		};
+	`).Invoke()
+	if _, err := setExitOverride([]js.Value{js.ValueOf(buildID), buildOverride}); err != nil {
+		t.Fatalf("Failed to set exit override: %v", err)
+	}
+	result, err = run([]js.Value{js.ValueOf(rootID), js.ValueOf([]any{"build", "--colr"})})
+	if err != nil {
+		t.Fatalf("Failed to run: %v", err)
+	}
+	if outcome = result.(map[string]any); outcome["exitCode"] != 9 || len(overridden) != 1 {
+		t.Errorf("Expected the subcommand's exit override alone, got %v", outcome)
+	}
+	result, err = run([]js.Value{js.ValueOf(rootID), js.ValueOf([]any{"--colr"})})
+	if err != nil {
+		t.Fatalf("Failed to run: %v", err)
+	}
+	if outcome = result.(map[string]any); outcome["exitCode"] != 7 {
+		t.Errorf("Expected the root's exit override for a root error, got %v", outcome)
+	}
+}
+
 func TestConfigurationManagement(t *testing.T) {
 	// Clear commands before test
-	commands = make(map[string]*cmd.Command)
-	nextID = 1
+	registry = NewRegistry()
 
 	// Create a command
 	result, err := createCommand([]js.Value{js.ValueOf("test")})
@@ -541,8 +915,7 @@ func TestConfigurationManagement(t *testing.T) {
 
 func TestUtilityFunctions(t *testing.T) {
 	// Clear commands before test
-	commands = make(map[string]*cmd.Command)
-	nextID = 1
+	registry = NewRegistry()
 
 	// Create multiple commands
 	for i := 0; i < 3; i++ {
@@ -583,8 +956,7 @@ func TestUtilityFunctions(t *testing.T) {
 
 func TestErrorHandling(t *testing.T) {
 	// Clear commands before test
-	commands = make(map[string]*cmd.Command)
-	nextID = 1
+	registry = NewRegistry()
 
 	tests := []struct {
 		name     string
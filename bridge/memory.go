@@ -460,6 +460,7 @@ func releaseObjectRef(this js.Value, args []js.Value) any {
 func getMemoryStats(this js.Value, args []js.Value) any {
 	mm := GetMemoryManager()
 	stats := mm.GetMemoryStats()
+	stats["registry"] = registry.Stats()
 
 	return js.ValueOf(map[string]any{
 		"success": true,
@@ -0,0 +1,328 @@
+//go:build wasm
+
+package main
+
+import (
+	"fmt"
+	"sync"
+	"syscall/js"
+
+	"github.com/rohitsoni-dev/gocommander/cmd"
+)
+
+// Registry holds the commands created through the bridge and the JavaScript
+// callbacks installed on them. It is safe for concurrent use, as calls that
+// wait on promises run in their own goroutines while other calls arrive.
+//
+// IDs have the form cmd_<generation>_<sequence>. Sequence numbers are never
+// reused and ClearAll starts a new generation, so an ID kept after its
+// command is gone never names another command. Clear removes a single
+// program, leaving the IDs of other programs valid.
+type Registry struct {
+	commands   map[string]*cmd.Command
+	ids        map[*cmd.Command]string
+	callbacks  map[string]*commandCallbacks
+	generation int
+	nextSeq    int
+	created    int
+	destroyed  int
+	mutex      sync.RWMutex
+}
+
+// registry is the registry used by the exported functions
+var registry = NewRegistry()
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		commands:   make(map[string]*cmd.Command),
+		ids:        make(map[*cmd.Command]string),
+		callbacks:  make(map[string]*commandCallbacks),
+		generation: 1,
+		nextSeq:    1,
+	}
+}
+
+// Register adds a command and returns its ID
+func (r *Registry) Register(command *cmd.Command) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	id := fmt.Sprintf("cmd_%d_%d", r.generation, r.nextSeq)
+	r.nextSeq++
+	r.created++
+	r.commands[id] = command
+	r.ids[command] = id
+	return id
+}
+
+// Get returns the command with the given ID. The error tells a destroyed or
+// cleared command apart from an ID that was never issued.
+func (r *Registry) Get(id string) (*cmd.Command, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if command, exists := r.commands[id]; exists {
+		return command, nil
+	}
+	return nil, r.lookupError(id)
+}
+
+// lookupError reports an ID that names no command. The caller holds the lock.
+func (r *Registry) lookupError(id string) error {
+	var generation, seq int
+	if n, _ := fmt.Sscanf(id, "cmd_%d_%d", &generation, &seq); n == 2 {
+		if generation < r.generation {
+			return fmt.Errorf("command %s was cleared", id)
+		}
+		if generation == r.generation && seq > 0 && seq < r.nextSeq {
+			return fmt.Errorf("command %s was destroyed", id)
+		}
+	}
+	return fmt.Errorf("command not found: %s", id)
+}
+
+// IDOf returns the ID under which a command is registered
+func (r *Registry) IDOf(command *cmd.Command) (string, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.idOf(command)
+}
+
+func (r *Registry) idOf(command *cmd.Command) (string, bool) {
+	id, exists := r.ids[command]
+	return id, exists
+}
+
+// All returns a snapshot of the registered commands by ID
+func (r *Registry) All() map[string]*cmd.Command {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	all := make(map[string]*cmd.Command, len(r.commands))
+	for id, command := range r.commands {
+		all[id] = command
+	}
+	return all
+}
+
+// Len returns the number of registered commands
+func (r *Registry) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return len(r.commands)
+}
+
+// Remove destroys a command together with the registered commands below it,
+// detaching it from its parent and releasing their callbacks. It returns the
+// IDs removed, the command's own first.
+func (r *Registry) Remove(id string) ([]string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	command, exists := r.commands[id]
+	if !exists {
+		return nil, r.lookupError(id)
+	}
+	return r.removeTree(id, command), nil
+}
+
+// Clear removes the program a command belongs to: its topmost registered
+// ancestor together with the registered commands below it. Other programs
+// keep their commands and IDs. It returns the IDs removed, the root's first.
+func (r *Registry) Clear(id string) ([]string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	command, exists := r.commands[id]
+	if !exists {
+		return nil, r.lookupError(id)
+	}
+
+	root, rootID := command, id
+	for parent := command.Parent; parent != nil; parent = parent.Parent {
+		if parentID, exists := r.ids[parent]; exists {
+			root, rootID = parent, parentID
+		}
+	}
+	return r.removeTree(rootID, root), nil
+}
+
+// removeTree detaches a command from its parent and removes it with the
+// registered commands below it. The caller holds the lock.
+func (r *Registry) removeTree(id string, command *cmd.Command) []string {
+	if parent := command.Parent; parent != nil {
+		for i, sub := range parent.Subcommands {
+			if sub == command {
+				parent.Subcommands = append(parent.Subcommands[:i], parent.Subcommands[i+1:]...)
+				break
+			}
+		}
+		command.Parent = nil
+	}
+
+	removed := []string{id}
+	r.remove(id)
+	var removeSubcommands func(command *cmd.Command)
+	removeSubcommands = func(command *cmd.Command) {
+		for _, sub := range command.Subcommands {
+			if subID, exists := r.idOf(sub); exists {
+				removed = append(removed, subID)
+				r.remove(subID)
+			}
+			removeSubcommands(sub)
+		}
+	}
+	removeSubcommands(command)
+
+	return removed
+}
+
+// ClearAll removes every command of every program and starts a new
+// generation of IDs
+func (r *Registry) ClearAll() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for id := range r.commands {
+		r.remove(id)
+	}
+	for id := range r.callbacks {
+		r.releaseCallbacks(id)
+	}
+	r.generation++
+	r.nextSeq = 1
+}
+
+// remove deletes a command entry and releases its callbacks. The caller
+// holds the lock.
+func (r *Registry) remove(id string) {
+	delete(r.ids, r.commands[id])
+	delete(r.commands, id)
+	r.destroyed++
+	r.releaseCallbacks(id)
+}
+
+// releaseCallbacks drops the functions retained for a command and releases
+// the handles of its pending promises, failing whatever awaits them. The
+// caller holds the lock.
+func (r *Registry) releaseCallbacks(id string) {
+	entry, exists := r.callbacks[id]
+	if !exists {
+		return
+	}
+	for pending := range entry.pending {
+		pending.release()
+		pending.done <- fmt.Errorf("command %s was destroyed while awaiting a promise", id)
+	}
+	delete(r.callbacks, id)
+}
+
+// callbacksFor returns the callbacks of a command, creating the entry if
+// needed. The caller holds the lock.
+func (r *Registry) callbacksFor(id string) *commandCallbacks {
+	entry, exists := r.callbacks[id]
+	if !exists {
+		entry = &commandCallbacks{
+			handlers: make(map[string]js.Value),
+			hooks:    make(map[cmd.HookEvent][]js.Value),
+			pending:  make(map[*pendingPromise]struct{}),
+		}
+		r.callbacks[id] = entry
+	}
+	return entry
+}
+
+// SetHandler retains fn as the named handler of a command
+func (r *Registry) SetHandler(id, name string, fn js.Value) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.callbacksFor(id).handlers[name] = fn
+}
+
+// Handler returns the named handler of a command
+func (r *Registry) Handler(id, name string) (js.Value, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if entry, exists := r.callbacks[id]; exists {
+		fn, exists := entry.handlers[name]
+		return fn, exists
+	}
+	return js.Value{}, false
+}
+
+// AddHook retains fn as a hook of a command
+func (r *Registry) AddHook(id string, event cmd.HookEvent, fn js.Value) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entry := r.callbacksFor(id)
+	entry.hooks[event] = append(entry.hooks[event], fn)
+}
+
+// RemoveHooks drops the hooks of a command for an event
+func (r *Registry) RemoveHooks(id string, event cmd.HookEvent) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if entry, exists := r.callbacks[id]; exists {
+		delete(entry.hooks, event)
+	}
+}
+
+// addPending tracks a promise awaited for a command
+func (r *Registry) addPending(id string, pending *pendingPromise) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.callbacksFor(id).pending[pending] = struct{}{}
+}
+
+// settlePending stops tracking a promise, releases its handles and reports
+// err to its waiter, unless the promise was already released along with its
+// command
+func (r *Registry) settlePending(id string, pending *pendingPromise, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entry, exists := r.callbacks[id]
+	if !exists {
+		return
+	}
+	if _, waiting := entry.pending[pending]; !waiting {
+		return
+	}
+	delete(entry.pending, pending)
+	pending.release()
+	pending.done <- err
+}
+
+// Stats returns the number of commands, retained functions and pending
+// promises, with the ID generation and the commands created and destroyed
+// over the registry's lifetime
+func (r *Registry) Stats() map[string]any {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var handlers, hooks, pending int
+	for _, entry := range r.callbacks {
+		handlers += len(entry.handlers)
+		for _, fns := range entry.hooks {
+			hooks += len(fns)
+		}
+		pending += len(entry.pending)
+	}
+
+	return map[string]any{
+		"commands":          len(r.commands),
+		"generation":        r.generation,
+		"created":           r.created,
+		"destroyed":         r.destroyed,
+		"retainedFunctions": handlers + hooks,
+		"pendingPromises":   pending,
+	}
+}
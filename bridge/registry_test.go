@@ -0,0 +1,146 @@
+//go:build wasm
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+	"testing"
+
+	"github.com/rohitsoni-dev/gocommander/cmd"
+)
+
+func TestRegistryIDs(t *testing.T) {
+	r := NewRegistry()
+
+	first := r.Register(cmd.NewCommand("first"))
+	second := r.Register(cmd.NewCommand("second"))
+	if first != "cmd_1_1" || second != "cmd_1_2" {
+		t.Errorf("Expected IDs cmd_1_1 and cmd_1_2, got %s and %s", first, second)
+	}
+
+	// Test that a destroyed ID is reported as such and never reused
+	if _, err := r.Remove(first); err != nil {
+		t.Fatalf("Failed to remove command: %v", err)
+	}
+	if _, err := r.Get(first); err == nil || !strings.Contains(err.Error(), "was destroyed") {
+		t.Errorf("Expected destroyed error, got %v", err)
+	}
+	if third := r.Register(cmd.NewCommand("third")); third != "cmd_1_3" {
+		t.Errorf("Expected ID cmd_1_3, got %s", third)
+	}
+
+	// Test that clearing starts a new generation
+	r.ClearAll()
+	if _, err := r.Get(second); err == nil || !strings.Contains(err.Error(), "was cleared") {
+		t.Errorf("Expected cleared error, got %v", err)
+	}
+	if fresh := r.Register(cmd.NewCommand("fresh")); fresh != "cmd_2_1" {
+		t.Errorf("Expected ID cmd_2_1, got %s", fresh)
+	}
+	if _, err := r.Get("cmd_2_9"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestRegistryRemoveSubcommands(t *testing.T) {
+	registry = NewRegistry()
+
+	root := cmd.NewCommand("app")
+	build := cmd.NewCommand("build")
+	target := cmd.NewCommand("target")
+	root.AddSubcommand(build)
+	build.AddSubcommand(target)
+
+	rootID := registry.Register(root)
+	buildID := registry.Register(build)
+	targetID := registry.Register(target)
+
+	action := js.FuncOf(func(this js.Value, args []js.Value) any { return nil })
+	defer action.Release()
+	if _, err := setAction([]js.Value{js.ValueOf(targetID), action.Value}); err != nil {
+		t.Fatalf("Failed to set action: %v", err)
+	}
+
+	result, err := destroyCommand([]js.Value{js.ValueOf(buildID)})
+	if err != nil {
+		t.Fatalf("Failed to destroy command: %v", err)
+	}
+	if removed := fmt.Sprint(result.(map[string]any)["removed"]); removed != fmt.Sprintf("[%s %s]", buildID, targetID) {
+		t.Errorf("Expected %s and %s to be removed, got %s", buildID, targetID, removed)
+	}
+	if len(root.Subcommands) != 0 || build.Parent != nil {
+		t.Error("Expected destroyed command to be detached from its parent")
+	}
+
+	stats := registry.Stats()
+	if stats["commands"] != 1 || stats["destroyed"] != 2 || stats["retainedFunctions"] != 0 {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+	if _, err := registry.Get(rootID); err != nil {
+		t.Errorf("Expected root to remain registered: %v", err)
+	}
+}
+
+func TestRegistryClearProgram(t *testing.T) {
+	r := NewRegistry()
+
+	app := cmd.NewCommand("app")
+	build := cmd.NewCommand("build")
+	app.AddSubcommand(build)
+	appID := r.Register(app)
+	buildID := r.Register(build)
+	other := cmd.NewCommand("other")
+	otherID := r.Register(other)
+
+	// Test that clearing through a subcommand removes its whole program only
+	removed, err := r.Clear(buildID)
+	if err != nil {
+		t.Fatalf("Failed to clear program: %v", err)
+	}
+	if fmt.Sprint(removed) != fmt.Sprintf("[%s %s]", appID, buildID) {
+		t.Errorf("Expected %s and %s to be removed, got %v", appID, buildID, removed)
+	}
+	if _, err := r.Get(otherID); err != nil {
+		t.Errorf("Expected the other program to remain registered: %v", err)
+	}
+	if _, exists := r.IDOf(app); exists {
+		t.Error("Expected a cleared command to have no ID")
+	}
+	if id, exists := r.IDOf(other); !exists || id != otherID {
+		t.Errorf("Expected IDOf to return %s, got %s", otherID, id)
+	}
+	if next := r.Register(cmd.NewCommand("next")); next != "cmd_1_4" {
+		t.Errorf("Expected the generation to be kept, got %s", next)
+	}
+}
+
+func TestRegistryConcurrentAccess(t *testing.T) {
+	r := NewRegistry()
+	done := make(chan bool, 10)
+
+	for i := 0; i < 10; i++ {
+		go func(id int) {
+			defer func() { done <- true }()
+
+			commandID := r.Register(cmd.NewCommand(fmt.Sprintf("command%d", id)))
+			if _, err := r.Get(commandID); err != nil {
+				t.Errorf("Failed to get command in goroutine %d: %v", id, err)
+				return
+			}
+			if _, err := r.Remove(commandID); err != nil {
+				t.Errorf("Failed to remove command in goroutine %d: %v", id, err)
+			}
+		}(i)
+	}
+
+	for i := 0; i < 10; i++ {
+		<-done
+	}
+
+	stats := r.Stats()
+	if stats["commands"] != 0 || stats["created"] != 10 || stats["destroyed"] != 10 {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+}
@@ -0,0 +1,274 @@
+//go:build wasm
+
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/rohitsoni-dev/gocommander/cmd"
+)
+
+// treeBuilder builds the commands described by a defineCommandTree spec. The
+// commands are registered only once the whole tree is built and valid, and
+// the JavaScript functions of the spec are installed after that, when the
+// commands have IDs.
+type treeBuilder struct {
+	commands []*cmd.Command
+	ids      map[*cmd.Command]string
+	installs []func()
+}
+
+// defineCommandTree builds a command with its options, arguments and
+// subcommands from a spec in one call and returns the IDs of the commands by
+// full name. A spec is an object, or a string holding it as JSON, with the
+// fields:
+//
+//	name, description, version, aliases, config,
+//	options:     [{flags, description, type, required, global, default, choices, env, conflicts, implies, parser}],
+//	arguments:   [{name, description, required, variadic, default, choices, parser}],
+//	subcommands: [spec, ...],
+//	action
+//
+// An option's type is string (the default), boolean, negatable, variadic,
+// number or count, and its implies is an array of option names or an object
+// of implied values. Nothing is registered when the spec or the resulting
+// tree is invalid.
+func defineCommandTree(args []js.Value) (any, error) {
+	if len(args) < 1 {
+		return nil, fmt.Errorf("spec is required")
+	}
+	spec := args[0]
+	if spec.Type() == js.TypeString {
+		parsed, err := invokeCallback(js.Global().Get("JSON").Get("parse"), spec)
+		if err != nil {
+			return nil, &cmd.ValidationError{Message: fmt.Sprintf("invalid JSON spec: %v", err)}
+		}
+		spec = parsed
+	}
+	if spec.Type() != js.TypeObject || spec.IsNull() {
+		return nil, fmt.Errorf("spec is required")
+	}
+
+	builder := &treeBuilder{ids: make(map[*cmd.Command]string)}
+	root, err := builder.buildCommand(spec, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := root.Validate(); err != nil {
+		return nil, &cmd.ValidationError{Command: root.Name, Message: err.Error()}
+	}
+
+	ids := make(map[string]any, len(builder.commands))
+	for _, command := range builder.commands {
+		id := registry.Register(command)
+		builder.ids[command] = id
+		ids[command.GetFullName()] = id
+	}
+	for _, install := range builder.installs {
+		install()
+	}
+
+	return map[string]any{
+		"id":  builder.ids[root],
+		"ids": ids,
+	}, nil
+}
+
+// buildCommand builds the command described by spec, below the command at path
+func (b *treeBuilder) buildCommand(spec js.Value, path string) (*cmd.Command, error) {
+	name := specString(spec, "name")
+	if name == "" {
+		return nil, &cmd.ValidationError{Command: path, Field: "name", Message: "command name is required"}
+	}
+	if path != "" {
+		path += " "
+	}
+	path += name
+
+	command := cmd.NewCommand(name)
+	command.Description = specString(spec, "description")
+	if version := specString(spec, "version"); version != "" {
+		command.SetVersion(version)
+	}
+	for _, alias := range specStrings(spec, "aliases") {
+		command.AddAlias(alias)
+	}
+	if config := spec.Get("config"); config.Type() == js.TypeObject {
+		applyCommandConfig(command, jsObjectToGoMap(config))
+	}
+	b.commands = append(b.commands, command)
+
+	optionSpecs := spec.Get("options")
+	for i := 0; i < specLength(optionSpecs); i++ {
+		option, err := b.buildOption(command, optionSpecs.Index(i), path, fmt.Sprintf("options[%d]", i))
+		if err != nil {
+			return nil, err
+		}
+		command.AddOption(option)
+	}
+
+	argumentSpecs := spec.Get("arguments")
+	for i := 0; i < specLength(argumentSpecs); i++ {
+		argument, err := b.buildArgument(command, argumentSpecs.Index(i), path, fmt.Sprintf("arguments[%d]", i))
+		if err != nil {
+			return nil, err
+		}
+		command.AddArgument(argument)
+	}
+
+	subcommandSpecs := spec.Get("subcommands")
+	for i := 0; i < specLength(subcommandSpecs); i++ {
+		subcommand, err := b.buildCommand(subcommandSpecs.Index(i), path)
+		if err != nil {
+			return nil, err
+		}
+		command.AddSubcommand(subcommand)
+	}
+
+	if fn := spec.Get("action"); fn.Type() == js.TypeFunction {
+		b.installs = append(b.installs, func() {
+			commandID := b.ids[command]
+			registry.SetHandler(commandID, "action", fn)
+			command.SetAction(jsActionHandler(commandID, fn))
+		})
+	} else if !fn.IsUndefined() {
+		return nil, &cmd.ValidationError{Command: path, Field: "action", Message: "action must be a function"}
+	}
+
+	return command, nil
+}
+
+// buildOption builds the option described by spec for command
+func (b *treeBuilder) buildOption(command *cmd.Command, spec js.Value, path, field string) (*cmd.Option, error) {
+	flags := specString(spec, "flags")
+	if flags == "" {
+		return nil, &cmd.ValidationError{Command: path, Field: field + ".flags", Message: "option flags are required"}
+	}
+	description := specString(spec, "description")
+
+	var option *cmd.Option
+	switch optionType := specString(spec, "type"); optionType {
+	case "", "string":
+		option = cmd.NewOption(flags, description)
+	case "boolean":
+		option = cmd.NewBooleanOption(flags, description)
+	case "negatable":
+		option = cmd.CreateNegatableOption(flags, description)
+	case "variadic":
+		option = cmd.NewVariadicOption(flags, description)
+	case "number":
+		option = cmd.CreateNumberOption(flags, description)
+	case "count":
+		option = cmd.NewCountOption(flags, description)
+	default:
+		return nil, &cmd.ValidationError{Command: path, Field: field + ".type", Message: fmt.Sprintf("invalid option type: %s", optionType)}
+	}
+
+	if required := spec.Get("required"); required.Type() == js.TypeBoolean {
+		option.SetRequired(required.Bool())
+	}
+	if global := spec.Get("global"); global.Type() == js.TypeBoolean {
+		option.SetGlobal(global.Bool())
+	}
+	if defaultValue := spec.Get("default"); !defaultValue.IsUndefined() {
+		value := jsValueToGo(defaultValue)
+		// Counts are ints, while whole JavaScript numbers convert to int64
+		if option.Type == cmd.OptionTypeCount {
+			count, ok := value.(int64)
+			if !ok || count < 0 {
+				return nil, &cmd.ValidationError{Command: path, Field: field + ".default", Message: "count default must be a non-negative integer"}
+			}
+			value = int(count)
+		}
+		option.SetDefault(value)
+	}
+	if choices := specStrings(spec, "choices"); choices != nil {
+		option.SetChoices(choices)
+	}
+	if env := specString(spec, "env"); env != "" {
+		option.SetEnv(env)
+	}
+	if conflicts := specStrings(spec, "conflicts"); conflicts != nil {
+		option.SetConflicts(conflicts)
+	}
+	if implies := spec.Get("implies"); !implies.IsUndefined() {
+		if _, err := applyImplies(option, implies); err != nil {
+			return nil, &cmd.ValidationError{Command: path, Field: field + ".implies", Message: err.Error()}
+		}
+	}
+
+	if fn := spec.Get("parser"); fn.Type() == js.TypeFunction {
+		option.SetParser(jsValueParser(fn))
+		b.installs = append(b.installs, func() {
+			registry.SetHandler(b.ids[command], "optionParser:"+option.Flags, fn)
+		})
+	} else if !fn.IsUndefined() {
+		return nil, &cmd.ValidationError{Command: path, Field: field + ".parser", Message: "parser must be a function"}
+	}
+
+	return option, nil
+}
+
+// buildArgument builds the argument described by spec for command
+func (b *treeBuilder) buildArgument(command *cmd.Command, spec js.Value, path, field string) (*cmd.Argument, error) {
+	name := specString(spec, "name")
+	if name == "" {
+		return nil, &cmd.ValidationError{Command: path, Field: field + ".name", Message: "argument name is required"}
+	}
+
+	argument := cmd.NewArgument(name, specString(spec, "description"))
+	if required := spec.Get("required"); required.Type() == js.TypeBoolean {
+		argument.SetRequired(required.Bool())
+	}
+	if variadic := spec.Get("variadic"); variadic.Type() == js.TypeBoolean {
+		argument.SetVariadic(variadic.Bool())
+	}
+	if defaultValue := spec.Get("default"); !defaultValue.IsUndefined() {
+		argument.SetDefault(jsValueToGo(defaultValue))
+	}
+	if choices := specStrings(spec, "choices"); choices != nil {
+		argument.SetChoices(choices)
+	}
+
+	if fn := spec.Get("parser"); fn.Type() == js.TypeFunction {
+		argument.SetParser(jsValueParser(fn))
+		b.installs = append(b.installs, func() {
+			registry.SetHandler(b.ids[command], "argumentParser:"+name, fn)
+		})
+	} else if !fn.IsUndefined() {
+		return nil, &cmd.ValidationError{Command: path, Field: field + ".parser", Message: "parser must be a function"}
+	}
+
+	return argument, nil
+}
+
+// specString returns the string field key of spec, or "" when it is not a string
+func specString(spec js.Value, key string) string {
+	if value := spec.Get(key); value.Type() == js.TypeString {
+		return value.String()
+	}
+	return ""
+}
+
+// specStrings returns the array field key of spec as strings, or nil when it
+// is not an array
+func specStrings(spec js.Value, key string) []string {
+	value := spec.Get(key)
+	if !value.InstanceOf(js.Global().Get("Array")) {
+		return nil
+	}
+	values := make([]string, value.Length())
+	for i := range values {
+		values[i] = value.Index(i).String()
+	}
+	return values
+}
+
+// specLength returns the length of an array field, or 0 when it is not an array
+func specLength(value js.Value) int {
+	if !value.InstanceOf(js.Global().Get("Array")) {
+		return 0
+	}
+	return value.Length()
+}
@@ -0,0 +1,199 @@
+//go:build wasm
+
+package main
+
+import (
+	"syscall/js"
+	"testing"
+
+	"github.com/rohitsoni-dev/gocommander/cmd"
+)
+
+func TestDefineCommandTree(t *testing.T) {
+	registry = NewRegistry()
+
+	var received []string
+	action := js.FuncOf(func(this js.Value, args []js.Value) any {
+		received = append(received, args[0].Index(0).String(), args[1].Get("target").String())
+		return nil
+	})
+	defer action.Release()
+
+	spec := js.ValueOf(map[string]any{
+		"name":    "app",
+		"version": "1.0.0",
+		"options": []any{
+			map[string]any{"flags": "-v, --verbose", "description": "Verbose output", "type": "boolean"},
+		},
+		"subcommands": []any{
+			map[string]any{
+				"name":    "build",
+				"aliases": []any{"b"},
+				"options": []any{
+					map[string]any{"flags": "--target <name>", "default": "debug", "choices": []any{"debug", "release"}},
+				},
+				"arguments": []any{
+					map[string]any{"name": "project", "required": true},
+				},
+				"action": action.Value,
+			},
+			map[string]any{"name": "clean"},
+		},
+	})
+
+	result, err := defineCommandTree([]js.Value{spec})
+	if err != nil {
+		t.Fatalf("Failed to define command tree: %v", err)
+	}
+
+	resultMap := result.(map[string]any)
+	ids := resultMap["ids"].(map[string]any)
+	if len(ids) != 3 || registry.Len() != 3 {
+		t.Fatalf("Expected 3 registered commands, got ids %v and %d commands", ids, registry.Len())
+	}
+	if resultMap["id"] != ids["app"] {
+		t.Errorf("Expected root id %v, got %v", ids["app"], resultMap["id"])
+	}
+
+	app, _ := registry.Get(ids["app"].(string))
+	build, _ := registry.Get(ids["app build"].(string))
+	if build == nil || build.Parent != app {
+		t.Fatalf("Expected 'app build' to be registered below 'app'")
+	}
+	if len(build.Aliases) != 1 || build.Aliases[0] != "b" {
+		t.Errorf("Expected alias 'b', got %v", build.Aliases)
+	}
+
+	// Test that the installed action runs through run
+	outcome, err := run([]js.Value{js.ValueOf(ids["app"]), js.ValueOf([]any{"b", "web", "--target", "release"})})
+	if err != nil {
+		t.Fatalf("Failed to run: %v", err)
+	}
+	if code := outcome.(map[string]any)["exitCode"]; code != 0 {
+		t.Errorf("Expected exit code 0, got %v", code)
+	}
+	if len(received) != 2 || received[0] != "web" || received[1] != "release" {
+		t.Errorf("Expected action to receive [web release], got %v", received)
+	}
+}
+
+func TestDefineCommandTreeCountOption(t *testing.T) {
+	builder := &treeBuilder{ids: make(map[*cmd.Command]string)}
+	command := cmd.NewCommand("app")
+	spec := js.ValueOf(map[string]any{"flags": "-v, --verbose", "type": "count", "default": 1})
+
+	option, err := builder.buildOption(command, spec, "app", "options[0]")
+	if err != nil {
+		t.Fatalf("Failed to build count option: %v", err)
+	}
+	command.AddOption(option)
+
+	result, err := cmd.NewParser().ParseCommand(command, []string{"-vv"})
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if verbose := result.Options["verbose"]; verbose != 3 {
+		t.Errorf("Expected verbose=3 counting from the default, got %v", verbose)
+	}
+
+	spec = js.ValueOf(map[string]any{"flags": "-v, --verbose", "type": "count", "default": 1.5})
+	if _, err := builder.buildOption(command, spec, "app", "options[0]"); err == nil {
+		t.Error("Expected error for a fractional count default")
+	}
+}
+
+func TestDefineCommandTreeRollback(t *testing.T) {
+	registry = NewRegistry()
+
+	tests := []struct {
+		name  string
+		spec  map[string]any
+		field string
+	}{
+		{
+			name: "duplicate subcommand",
+			spec: map[string]any{
+				"name":        "app",
+				"subcommands": []any{map[string]any{"name": "build"}, map[string]any{"name": "build"}},
+			},
+		},
+		{
+			name: "option without flags",
+			spec: map[string]any{
+				"name": "app",
+				"subcommands": []any{
+					map[string]any{"name": "build", "options": []any{map[string]any{"description": "Target"}}},
+				},
+			},
+			field: "options[0].flags",
+		},
+		{
+			name: "invalid option type",
+			spec: map[string]any{
+				"name":    "app",
+				"options": []any{map[string]any{"flags": "--port <n>", "type": "port"}},
+			},
+			field: "options[0].type",
+		},
+		{
+			name: "invalid implies",
+			spec: map[string]any{
+				"name":    "app",
+				"options": []any{map[string]any{"flags": "--quick", "type": "boolean", "implies": "level"}},
+			},
+			field: "options[0].implies",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := defineCommandTree([]js.Value{js.ValueOf(tt.spec)})
+			validationErr, ok := err.(*cmd.ValidationError)
+			if !ok {
+				t.Fatalf("Expected ValidationError, got %T: %v", err, err)
+			}
+			if validationErr.Field != tt.field {
+				t.Errorf("Expected field %q, got %q", tt.field, validationErr.Field)
+			}
+			if stats := registry.Stats(); stats["commands"] != 0 || stats["created"] != 0 {
+				t.Errorf("Expected nothing to be registered, got %v", stats)
+			}
+		})
+	}
+}
+
+func TestDefineCommandTreeJSON(t *testing.T) {
+	registry = NewRegistry()
+
+	spec := `{
+		"name": "app",
+		"options": [
+			{"flags": "--dry-run", "type": "boolean", "global": true},
+			{"flags": "--quick", "type": "boolean", "global": true, "implies": {"level": "fast"}},
+			{"flags": "--level <name>", "global": true}
+		],
+		"subcommands": [{"name": "deploy"}]
+	}`
+	result, err := defineCommandTree([]js.Value{js.ValueOf(spec)})
+	if err != nil {
+		t.Fatalf("Failed to define command tree: %v", err)
+	}
+	ids := result.(map[string]any)["ids"].(map[string]any)
+
+	// Test that global options and implied values apply after the subcommand
+	app, _ := registry.Get(ids["app"].(string))
+	parsed, err := cmd.NewParser().ParseCommand(app, []string{"deploy", "--quick", "--dry-run"})
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if parsed.Options["level"] != "fast" || parsed.Options["dry-run"] != true {
+		t.Errorf("Expected global dry-run and implied level, got %v", parsed.Options)
+	}
+
+	if _, err := defineCommandTree([]js.Value{js.ValueOf(`{"name": "broken"`)}); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+	if registry.Len() != 2 {
+		t.Errorf("Expected only the first tree to be registered, got %d commands", registry.Len())
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+)
+
+// expandOptionPrefix returns the full name of the long option abbreviated by
+// flag (without dashes). Exact names, including hidden options, are returned
+// unchanged; otherwise flag must be the prefix of exactly one visible option
+// of the command, its inherited global options or, with positional options,
+// its parents. Unmatched flags are returned unchanged for the usual
+// unknown-option handling.
+func (p *Parser) expandOptionPrefix(cmd *Command, flag string) (string, error) {
+	if flag == "" || p.findOptionWithContext(cmd, flag, TokenLongOption) != nil {
+		return flag, nil
+	}
+
+	options := append(slices.Clone(cmd.Options), cmd.inheritedGlobalOptions()...)
+	if p.EnablePositionalOptions {
+		for parent := cmd.Parent; parent != nil; parent = parent.Parent {
+			options = append(options, parent.Options...)
+		}
+	}
+
+	var names []string
+	var matched []*Option
+	for _, option := range options {
+		if option.Hidden || slices.Contains(matched, option) {
+			continue
+		}
+		for _, long := range option.longFlags() {
+			if name := strings.TrimPrefix(long, "--"); strings.HasPrefix(name, flag) {
+				names = append(names, long)
+				matched = append(matched, option)
+				break
+			}
+		}
+	}
+
+	switch len(matched) {
+	case 0:
+		return flag, nil
+	case 1:
+		return strings.TrimPrefix(names[0], "--"), nil
+	}
+	return "", NewAmbiguousOptionError("--"+flag, names)
+}
+
+// expandSubcommandPrefix returns the name of the subcommand abbreviated by
+// name. Exact names and aliases, including hidden ones, are returned
+// unchanged; otherwise name must be the prefix of the name or an alias of
+// exactly one visible subcommand. Unmatched names are returned unchanged so
+// they can be taken as arguments.
+func (p *Parser) expandSubcommandPrefix(cmd *Command, name string) (string, error) {
+	if name == "" || cmd.FindSubcommandByNameOrAlias(name) != nil {
+		return name, nil
+	}
+
+	var names []string
+	var matched []*Command
+	for _, sub := range cmd.Subcommands {
+		if sub.Hidden {
+			continue
+		}
+		for _, candidate := range append([]string{sub.Name}, sub.Aliases...) {
+			if strings.HasPrefix(candidate, name) {
+				names = append(names, candidate)
+				matched = append(matched, sub)
+				break
+			}
+		}
+	}
+
+	switch len(matched) {
+	case 0:
+		return name, nil
+	case 1:
+		return matched[0].Name, nil
+	}
+	return "", NewAmbiguousCommandError(name, names)
+}
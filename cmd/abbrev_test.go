@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func newAbbrevTestCommand() *Command {
+	root := NewCommand("tool")
+	root.AllowAbbreviations = true
+	root.AddOption(NewBooleanOption("--verbose", "verbose output"))
+	root.AddOption(NewOption("--version-file <path>", "version file"))
+	root.AddOption(NewOption("--output <file>", "output file"))
+	root.AddOption(NewOption("--no-color", "disable color"))
+	root.AddOption(NewBooleanOption("--debug-internal", "internal").SetHidden(true))
+	root.AddGlobalOption(NewOption("--profile <name>", "profile"))
+
+	deploy := NewCommand("deploy").SetAliases([]string{"ship"})
+	deploy.CopyInheritedSettings(root)
+	deploy.AddOption(NewBooleanOption("--dry-run", "dry run"))
+	root.AddSubcommand(deploy)
+	root.AddSubcommand(NewCommand("describe"))
+	root.AddSubcommand(&Command{Name: "destroy", Hidden: true})
+	return root
+}
+
+func TestAbbreviatedOptions(t *testing.T) {
+	tests := []struct {
+		args     []string
+		key      string
+		expected any
+	}{
+		{[]string{"--verb"}, "verbose", true},
+		{[]string{"--out", "a.txt"}, "output", "a.txt"},
+		{[]string{"--out=b.txt"}, "output", "b.txt"},
+		{[]string{"--no-c"}, "color", false},
+		{[]string{"--debug-internal"}, "debug-internal", true},
+		{[]string{"dep", "--dry", "--prof", "dev"}, "dry-run", true},
+		{[]string{"dep", "--prof", "dev"}, "profile", "dev"},
+	}
+	for _, tt := range tests {
+		root := newAbbrevTestCommand()
+		result, err := root.NewParser().ParseCommand(root, tt.args)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", tt.args, err)
+			continue
+		}
+		if got := result.Options[tt.key]; !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%v: expected %s=%v, got %v", tt.args, tt.key, tt.expected, got)
+		}
+	}
+
+	root := newAbbrevTestCommand()
+	_, err := root.NewParser().ParseCommand(root, []string{"--ver"})
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) || ambiguous.Code != "commander.ambiguousOption" {
+		t.Fatalf("Expected ambiguous option error, got %v", err)
+	}
+	if !reflect.DeepEqual(ambiguous.Candidates, []string{"--verbose", "--version-file"}) {
+		t.Errorf("Unexpected candidates: %v", ambiguous.Candidates)
+	}
+
+	// Hidden options only match exactly
+	if _, err := root.NewParser().ParseCommand(root, []string{"--debug"}); err == nil {
+		t.Error("Expected a prefix of a hidden option to be unknown")
+	}
+
+	// Abbreviations are opt-in
+	strict := newAbbrevTestCommand()
+	strict.AllowAbbreviations = false
+	if _, err := strict.NewParser().ParseCommand(strict, []string{"--verb"}); err == nil {
+		t.Error("Expected --verb to be unknown without AllowAbbreviations")
+	}
+}
+
+func TestAbbreviatedSubcommands(t *testing.T) {
+	for _, args := range [][]string{{"depl"}, {"sh"}, {"deploy"}} {
+		root := newAbbrevTestCommand()
+		result, err := root.NewParser().ParseCommand(root, args)
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", args, err)
+			continue
+		}
+		if result.Command.Name != "deploy" {
+			t.Errorf("%v: expected deploy, got %s", args, result.Command.Name)
+		}
+	}
+
+	root := newAbbrevTestCommand()
+	_, err := root.NewParser().ParseCommand(root, []string{"de"})
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) || ambiguous.Code != "commander.ambiguousCommand" {
+		t.Fatalf("Expected ambiguous command error, got %v", err)
+	}
+	// The hidden destroy command is not a candidate
+	if !reflect.DeepEqual(ambiguous.Candidates, []string{"deploy", "describe"}) {
+		t.Errorf("Unexpected candidates: %v", ambiguous.Candidates)
+	}
+
+	if result, err := root.NewParser().ParseCommand(root, []string{"destr"}); err != nil || result.Command != root {
+		t.Errorf("Expected a prefix of a hidden command to stay an operand, got %v", err)
+	}
+	if result, err := root.NewParser().ParseCommand(root, []string{"destroy"}); err != nil || result.Command.Name != "destroy" {
+		t.Errorf("Expected hidden command to match exactly, got %v", err)
+	}
+}
@@ -19,10 +19,15 @@ type Argument struct {
 	Default     any
 	Choices     []string
 	Parser      ArgumentParser
+	TypeName    string
 
 	// Commander.js compatibility fields
 	ArgRequired bool
 	ArgOptional bool
+
+	// Value validation and shell completion
+	Validator func(any) error
+	Completer *Completer
 }
 
 // NewArgument creates a new argument with the given name and description
@@ -108,8 +113,65 @@ func (a *Argument) SetParser(parser ArgumentParser) *Argument {
 	return a
 }
 
+// SetValueType parses the argument with the value type's parser, names its
+// value in help and completes it with the type's completer, if any
+func (a *Argument) SetValueType(valueType ValueType) *Argument {
+	a.Parser = valueType.Parser
+	a.TypeName = valueType.Name
+	if valueType.Completer != nil {
+		a.Completer = valueType.Completer
+	}
+	return a
+}
+
+// SetValidator sets a function that validates each parsed value
+func (a *Argument) SetValidator(validator func(any) error) *Argument {
+	a.Validator = validator
+	return a
+}
+
+// SetCompleter sets how shell completion suggests values for the argument
+func (a *Argument) SetCompleter(completer *Completer) *Argument {
+	a.Completer = completer
+	return a
+}
+
+// SetFileValidator validates each value with ValidateFileExists and
+// completes the argument with file names
+func (a *Argument) SetFileValidator() *Argument {
+	a.Validator = ValidateFileExists
+	a.Completer = FileCompleter()
+	return a
+}
+
+// SetDirectoryValidator validates each value with ValidateDirectoryExists
+// and completes the argument with directory names
+func (a *Argument) SetDirectoryValidator() *Argument {
+	a.Validator = ValidateDirectoryExists
+	a.Completer = DirectoryCompleter()
+	return a
+}
+
 // ParseValue parses a string value using the argument's parser or default parsing
 func (a *Argument) ParseValue(value string, previous any) (any, error) {
+	parsed, err := a.parseValue(value, previous)
+	if err != nil || a.Validator == nil {
+		return parsed, err
+	}
+
+	// Validate the newly parsed element of variadic values
+	item := parsed
+	if items, ok := parsed.([]any); ok && a.Variadic && len(items) > 0 {
+		item = items[len(items)-1]
+	}
+	if err := a.Validator(item); err != nil {
+		return nil, fmt.Errorf("invalid value for argument '%s': %v", a.Name, err)
+	}
+	return parsed, nil
+}
+
+// parseValue parses a string value before validation
+func (a *Argument) parseValue(value string, previous any) (any, error) {
 	// Enhanced validation before parsing
 	if err := a.validateValueFormat(value); err != nil {
 		return nil, err
@@ -0,0 +1,373 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Get returns the value of an option converted to T. Numbers are converted
+// between integer and float types when no precision is lost, and strings are
+// parsed into numbers, booleans and durations.
+func Get[T any](pc *ParsedCommand, key string) (T, error) {
+	var zero T
+	_, value, ok := pc.lookupOption(key)
+	if !ok {
+		return zero, fmt.Errorf("option '%s' is not set", key)
+	}
+
+	converted, err := convertValue(value, reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return zero, fmt.Errorf("option '%s': %v", key, err)
+	}
+	// A nil value of an interface type has no dynamic type to assert
+	result, _ := converted.Interface().(T)
+	return result, nil
+}
+
+// GetString returns the value of an option as a string
+func (pc *ParsedCommand) GetString(key string) (string, error) {
+	return Get[string](pc, key)
+}
+
+// GetBool returns the value of an option as a bool
+func (pc *ParsedCommand) GetBool(key string) (bool, error) {
+	return Get[bool](pc, key)
+}
+
+// GetInt returns the value of an option as an int
+func (pc *ParsedCommand) GetInt(key string) (int, error) {
+	return Get[int](pc, key)
+}
+
+// GetFloat returns the value of an option as a float64
+func (pc *ParsedCommand) GetFloat(key string) (float64, error) {
+	return Get[float64](pc, key)
+}
+
+// GetDuration returns the value of an option as a time.Duration
+func (pc *ParsedCommand) GetDuration(key string) (time.Duration, error) {
+	return Get[time.Duration](pc, key)
+}
+
+// GetStringSlice returns the value of an option as a []string
+func (pc *ParsedCommand) GetStringSlice(key string) ([]string, error) {
+	return Get[[]string](pc, key)
+}
+
+// lookupOption finds an option value by key, or by any of the option's
+// flags, and returns the key it is stored under
+func (pc *ParsedCommand) lookupOption(key string) (string, any, bool) {
+	if value, ok := pc.Options[key]; ok {
+		return key, value, true
+	}
+	if pc.Command == nil {
+		return "", nil, false
+	}
+
+	option := pc.Command.FindOption(strings.TrimLeft(key, "-"))
+	if option == nil {
+		return "", nil, false
+	}
+	for _, k := range []string{option.Long, option.Short} {
+		if value, ok := pc.Options[k]; ok && k != "" {
+			return k, value, true
+		}
+	}
+	return "", nil, false
+}
+
+// lookupArgument finds an argument value by position or by name
+func (pc *ParsedCommand) lookupArgument(ref string) (any, bool) {
+	index, err := strconv.Atoi(ref)
+	if err != nil {
+		index = -1
+		if pc.Command != nil {
+			for i, arg := range pc.Command.Arguments {
+				if arg.Name == ref {
+					index = i
+					break
+				}
+			}
+		}
+	}
+
+	if index < 0 || index >= len(pc.Arguments) || pc.Arguments[index] == nil {
+		return nil, false
+	}
+	return pc.Arguments[index], true
+}
+
+// Bind fills the struct pointed to by dst from the parse result. Fields are
+// selected with tags:
+//
+//	Port    int      `opt:"port" env:"PORT" default:"8080"`
+//	Service string   `arg:"0"`
+//	Hosts   []string `arg:"hosts"`
+//
+// An option set on the command line, from its env var or from a config file
+// wins over the env tag, which wins over the option's own default, which wins
+// over the default tag. Implicit defaults such as false for a boolean or an
+// empty list for a variadic option do not override the default tag. Embedded
+// structs are bound recursively.
+func (pc *ParsedCommand) Bind(dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind destination must be a non-nil pointer to a struct, got %T", dst)
+	}
+	return pc.bindStruct(v.Elem())
+}
+
+// bindStruct binds the tagged fields of a struct value
+func (pc *ParsedCommand) bindStruct(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := pc.bindStruct(v.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		value, source, ok := pc.bindValue(field)
+		if !ok {
+			continue
+		}
+		converted, err := convertValue(value, field.Type)
+		if err != nil {
+			return fmt.Errorf("cannot bind %s to field %s: %v", source, field.Name, err)
+		}
+		v.Field(i).Set(converted)
+	}
+	return nil
+}
+
+// bindValue resolves the value for a tagged field and describes its source
+func (pc *ParsedCommand) bindValue(field reflect.StructField) (any, string, bool) {
+	if ref, ok := field.Tag.Lookup("arg"); ok && ref != "-" {
+		if value, found := pc.lookupArgument(ref); found {
+			return value, fmt.Sprintf("argument '%s'", ref), true
+		}
+		return pc.bindFallback(field, fmt.Sprintf("argument '%s'", ref))
+	}
+
+	key, ok := field.Tag.Lookup("opt")
+	if !ok || key == "-" {
+		return nil, "", false
+	}
+	source := fmt.Sprintf("option '%s'", key)
+
+	storedKey, value, found := pc.lookupOption(key)
+	if found && pc.GetOptionValueSource(storedKey) != ValueSourceDefault {
+		return value, source, true
+	}
+	if env, ok := field.Tag.Lookup("env"); ok {
+		if envValue, set := os.LookupEnv(env); set {
+			return tagValue(envValue, field.Type), fmt.Sprintf("env var '%s'", env), true
+		}
+	}
+	// Implicit option defaults such as false or an empty list yield to the tag
+	if found && (showDefault(value) || !hasTag(field, "default")) {
+		return value, source, true
+	}
+	if defaultValue, ok := field.Tag.Lookup("default"); ok {
+		return tagValue(defaultValue, field.Type), source + " default", true
+	}
+	return nil, "", false
+}
+
+// hasTag reports whether the field carries the named tag
+func hasTag(field reflect.StructField, name string) bool {
+	_, ok := field.Tag.Lookup(name)
+	return ok
+}
+
+// bindFallback resolves an argument field from its env and default tags
+func (pc *ParsedCommand) bindFallback(field reflect.StructField, source string) (any, string, bool) {
+	if env, ok := field.Tag.Lookup("env"); ok {
+		if envValue, set := os.LookupEnv(env); set {
+			return tagValue(envValue, field.Type), fmt.Sprintf("env var '%s'", env), true
+		}
+	}
+	if defaultValue, ok := field.Tag.Lookup("default"); ok {
+		return tagValue(defaultValue, field.Type), source + " default", true
+	}
+	return nil, "", false
+}
+
+// tagValue splits comma-separated env and default tag values for slice fields
+func tagValue(value string, t reflect.Type) any {
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		parts := strings.Split(value, ",")
+		items := make([]any, len(parts))
+		for i, part := range parts {
+			items[i] = strings.TrimSpace(part)
+		}
+		return items
+	}
+	return value
+}
+
+// convertValue converts a parsed value to the target type
+func convertValue(value any, t reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Zero(t), nil
+	}
+	v := reflect.ValueOf(value)
+
+	if t == durationType {
+		switch x := value.(type) {
+		case time.Duration:
+			return reflect.ValueOf(x), nil
+		case string:
+			d, err := time.ParseDuration(x)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("invalid duration %q", x)
+			}
+			return reflect.ValueOf(d), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot convert %T to %s", value, t)
+	}
+
+	if v.Type().AssignableTo(t) {
+		converted := reflect.New(t).Elem()
+		converted.Set(v)
+		return converted, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem, err := convertValue(value, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(elem)
+		return ptr, nil
+
+	case reflect.String:
+		if v.Kind() == reflect.String {
+			return v.Convert(t), nil
+		}
+
+	case reflect.Bool:
+		switch v.Kind() {
+		case reflect.Bool:
+			return v.Convert(t), nil
+		case reflect.String:
+			b, err := strconv.ParseBool(v.String())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("invalid boolean %q", v.String())
+			}
+			return reflect.ValueOf(b).Convert(t), nil
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt64(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		converted := reflect.New(t).Elem()
+		if converted.OverflowInt(n) {
+			return reflect.Value{}, fmt.Errorf("value %d overflows %s", n, t)
+		}
+		converted.SetInt(n)
+		return converted, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := toInt64(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		converted := reflect.New(t).Elem()
+		if n < 0 || converted.OverflowUint(uint64(n)) {
+			return reflect.Value{}, fmt.Errorf("value %d overflows %s", n, t)
+		}
+		converted.SetUint(uint64(n))
+		return converted, nil
+
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat64(v)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(f).Convert(t), nil
+
+	case reflect.Slice:
+		var items []reflect.Value
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			for i := 0; i < v.Len(); i++ {
+				items = append(items, v.Index(i))
+			}
+		} else {
+			items = []reflect.Value{v}
+		}
+
+		converted := reflect.MakeSlice(t, 0, len(items))
+		for i, item := range items {
+			elem, err := convertValue(item.Interface(), t.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("item %d: %v", i, err)
+			}
+			converted = reflect.Append(converted, elem)
+		}
+		return converted, nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("cannot convert %T (%v) to %s", value, value, t)
+}
+
+// toInt64 converts an integer, an integral float or a numeric string
+func toInt64(v reflect.Value) (int64, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("value %d overflows int64", v.Uint())
+		}
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f > math.MaxInt64 {
+			return 0, fmt.Errorf("value %v is not an integer", f)
+		}
+		return int64(f), nil
+	case reflect.String:
+		n, err := strconv.ParseInt(strings.TrimSpace(v.String()), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid integer %q", v.String())
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("cannot convert %s to an integer", v.Type())
+}
+
+// toFloat64 converts a number or a numeric string
+func toFloat64(v reflect.Value) (float64, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", v.String())
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("cannot convert %s to a number", v.Type())
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newBindTestCommand() *Command {
+	root := NewCommand("serve")
+	root.AddOption(CreateNumberOption("-p, --port <n>", "port"))
+	root.AddOption(NewOption("--ratio <n>", "ratio").SetParser(DefaultFloatParser))
+	root.AddOption(NewOption("--timeout <d>", "timeout").SetDefault("30s"))
+	root.AddOption(NewBooleanOption("-v, --verbose", "verbose output"))
+	root.AddOption(NewVariadicOption("--tag <tags...>", "tags"))
+	root.AddOption(NewOption("--host <name>", "host").SetDefault("localhost"))
+	root.AddArgument(NewArgument("<service>", "service"))
+	root.AddArgument(NewArgument("[replicas...]", "replica ids"))
+	return root
+}
+
+func TestTypedGetters(t *testing.T) {
+	result, err := NewParser().ParseCommand(newBindTestCommand(),
+		[]string{"api", "-p", "8080", "--ratio", "2", "--tag", "a", "b", "-v"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if port, err := result.GetInt("port"); err != nil || port != 8080 {
+		t.Errorf("GetInt(port) = %d, %v", port, err)
+	}
+	// A float parser yields float64, which converts to int without loss
+	if ratio, err := Get[int](result, "ratio"); err != nil || ratio != 2 {
+		t.Errorf("Get[int](ratio) = %d, %v", ratio, err)
+	}
+	if port, err := Get[uint16](result, "-p"); err != nil || port != 8080 {
+		t.Errorf("Get[uint16](-p) = %d, %v", port, err)
+	}
+	if timeout, err := result.GetDuration("timeout"); err != nil || timeout != 30*time.Second {
+		t.Errorf("GetDuration(timeout) = %v, %v", timeout, err)
+	}
+	if verbose, err := result.GetBool("verbose"); err != nil || !verbose {
+		t.Errorf("GetBool(verbose) = %v, %v", verbose, err)
+	}
+	if tags, err := result.GetStringSlice("tag"); err != nil || !reflect.DeepEqual(tags, []string{"a", "b"}) {
+		t.Errorf("GetStringSlice(tag) = %v, %v", tags, err)
+	}
+	if host, err := result.GetString("host"); err != nil || host != "localhost" {
+		t.Errorf("GetString(host) = %q, %v", host, err)
+	}
+
+	if _, err := result.GetInt("host"); err == nil || !strings.Contains(err.Error(), "option 'host'") {
+		t.Errorf("Expected type mismatch error naming the option, got %v", err)
+	}
+	if _, err := result.GetString("missing"); err == nil {
+		t.Error("Expected error for an unset option")
+	}
+
+	// Numeric strings are decimal even with a leading zero
+	for value, want := range map[string]int{"010": 10, "08": 8} {
+		result.setOption("host", value, ValueSourceCLI)
+		if n, err := result.GetInt("host"); err != nil || n != want {
+			t.Errorf("GetInt(%q) = %d, %v", value, n, err)
+		}
+	}
+
+	// A parser can store nil, which reads back as the zero value
+	result.setOption("host", nil, ValueSourceCLI)
+	if host, err := Get[any](result, "host"); err != nil || host != nil {
+		t.Errorf("Get[any](host) = %v, %v", host, err)
+	}
+	if host, err := result.GetString("host"); err != nil || host != "" {
+		t.Errorf("GetString(host) = %q, %v", host, err)
+	}
+}
+
+func TestBind(t *testing.T) {
+	type Common struct {
+		Verbose bool `opt:"verbose"`
+	}
+	type ServeConfig struct {
+		Common
+		Port     int           `opt:"port" env:"TEST_BIND_PORT" default:"9000"`
+		Host     string        `opt:"host" env:"TEST_BIND_HOST"`
+		Timeout  time.Duration `opt:"timeout"`
+		Workers  int           `opt:"workers" default:"4"`
+		Tags     []string      `opt:"tag" default:"x, y"`
+		Service  string        `arg:"0"`
+		Replicas []int         `arg:"replicas"`
+		Region   string        `arg:"region" default:"eu"`
+		Ignored  string
+	}
+
+	t.Setenv("TEST_BIND_HOST", "example.com")
+	t.Setenv("TEST_BIND_PORT", "7000")
+
+	result, err := NewParser().ParseCommand(newBindTestCommand(), []string{"-p", "8080", "-v", "api", "1", "2"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var cfg ServeConfig
+	if err := result.Bind(&cfg); err != nil {
+		t.Fatalf("Unexpected bind error: %v", err)
+	}
+
+	expected := ServeConfig{
+		Common:   Common{Verbose: true},
+		Port:     8080,          // command line wins over the env tag
+		Host:     "example.com", // env tag wins over the option default
+		Timeout:  30 * time.Second,
+		Workers:  4,
+		Tags:     []string{"x", "y"},
+		Service:  "api",
+		Replicas: []int{1, 2},
+		Region:   "eu",
+	}
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Errorf("Unexpected binding:\n got: %+v\nwant: %+v", cfg, expected)
+	}
+
+	var bad struct {
+		Service int `arg:"service"`
+	}
+	if err := result.Bind(&bad); err == nil || !strings.Contains(err.Error(), "field Service") {
+		t.Errorf("Expected type mismatch error naming the field, got %v", err)
+	}
+	if err := result.Bind(cfg); err == nil {
+		t.Error("Expected error when binding to a non-pointer")
+	}
+}
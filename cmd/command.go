@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"slices"
 	"strings"
+	"syscall"
+	"time"
 )
 
 // ActionHandler represents a function that handles command execution
@@ -16,6 +20,15 @@ type HookHandler func(thisCommand *Command, actionCommand *Command) error
 // AsyncActionHandler represents an async action handler
 type AsyncActionHandler func(args []string, opts map[string]any) <-chan error
 
+// ContextActionHandler represents an action handler that observes cancellation and deadlines
+type ContextActionHandler func(ctx context.Context, args []string, opts map[string]any) error
+
+// ContextAsyncActionHandler represents an async action handler that observes cancellation
+type ContextAsyncActionHandler func(ctx context.Context, args []string, opts map[string]any) <-chan error
+
+// ContextHookHandler represents a lifecycle hook that observes cancellation
+type ContextHookHandler func(ctx context.Context, thisCommand *Command, actionCommand *Command) error
+
 // HookEvent represents different types of lifecycle events
 type HookEvent string
 
@@ -30,6 +43,11 @@ type LifecycleHooks struct {
 	PreAction     []HookHandler
 	PostAction    []HookHandler
 	PreSubcommand []HookHandler
+
+	// Context-aware variants, run before the plain hooks of the same event
+	PreActionContext     []ContextHookHandler
+	PostActionContext    []ContextHookHandler
+	PreSubcommandContext []ContextHookHandler
 }
 
 // Command represents a CLI command with options, arguments, and subcommands
@@ -45,6 +63,9 @@ type Command struct {
 	Hidden      bool
 	Version     string
 
+	// Option groups with their constraints and help headings
+	OptionGroups []*OptionGroup
+
 	// Commander.js compatibility fields
 	Usage           string
 	Summary         string
@@ -59,6 +80,12 @@ type Command struct {
 	Hooks       *LifecycleHooks
 	AsyncAction AsyncActionHandler
 
+	// Context-aware execution
+	ContextAction      ContextActionHandler
+	ContextAsyncAction ContextAsyncActionHandler
+	Timeout            time.Duration
+	CancelSignals      []os.Signal
+
 	// Configuration options
 	AllowUnknownOption          bool
 	AllowExcessArguments        bool
@@ -66,10 +93,17 @@ type Command struct {
 	PassThroughOptions          bool
 	StoreOptionsAsProperties    bool
 	CombineFlagAndOptionalValue bool
+	AllowAbbreviations          bool
+	StopAtFirstPositional       bool
+	StopAtFirstUnknown          bool
+	CollectErrors               bool
 
 	// Help configuration
 	HelpOption               *Option
 	HelpCommand              *Command
+	HelpConfiguration        *Help
+	VersionOption            *Option
+	DisableHelpCommand       bool
 	ShowHelpAfterError       bool
 	ShowSuggestionAfterError bool
 
@@ -90,6 +124,10 @@ type Command struct {
 	// Advanced parsing configuration
 	PassThroughArgs []string
 	UnknownOptions  []string
+
+	// Configuration file support
+	ConfigOption   *Option
+	ConfigDecoders map[string]ConfigDecoder
 }
 
 // OutputConfiguration represents output stream configuration
@@ -174,6 +212,15 @@ func (c *Command) Validate() error {
 		}
 	}
 
+	// Grouped options must belong to the command
+	for _, group := range c.OptionGroups {
+		for _, option := range group.Options {
+			if !slices.Contains(c.Options, option) {
+				return fmt.Errorf("option %s of group '%s' is not an option of command '%s'", option.Flags, group.Name, c.Name)
+			}
+		}
+	}
+
 	// Validate arguments
 	variadicFound := false
 	for i, arg := range c.Arguments {
@@ -230,6 +277,34 @@ func (c *Command) AddOption(option *Option) *Command {
 	return c
 }
 
+// AddGlobalOption adds an option that every subcommand accepts as well
+func (c *Command) AddGlobalOption(option *Option) *Command {
+	return c.AddOption(option.SetGlobal(true))
+}
+
+// AddOptionGroup adds the options of group to the command and enforces the
+// group's constraints when parsing. Grouped options are listed under the
+// group's name in help.
+func (c *Command) AddOptionGroup(group *OptionGroup) *Command {
+	c.OptionGroups = append(c.OptionGroups, group)
+	for _, option := range group.Options {
+		if !slices.Contains(c.Options, option) {
+			c.AddOption(option)
+		}
+	}
+	return c
+}
+
+// optionGroup returns the group the option belongs to, or nil
+func (c *Command) optionGroup(option *Option) *OptionGroup {
+	for _, group := range c.OptionGroups {
+		if slices.Contains(group.Options, option) {
+			return group
+		}
+	}
+	return nil
+}
+
 // AddArgument adds an argument to the command
 func (c *Command) AddArgument(argument *Argument) *Command {
 	c.Arguments = append(c.Arguments, argument)
@@ -280,6 +355,46 @@ func (c *Command) FindOption(flag string) *Option {
 	return nil
 }
 
+// findGlobalOption finds a global option of an ancestor matching flag,
+// searching the nearest ancestor first
+func (c *Command) findGlobalOption(flag string) *Option {
+	for parent := c.Parent; parent != nil; parent = parent.Parent {
+		for _, option := range parent.Options {
+			if option.Global && option.Matches(flag) {
+				return option
+			}
+		}
+	}
+	return nil
+}
+
+// inheritedGlobalOptions returns the global options of the ancestors, nearest
+// first, leaving out those shadowed by an option of the command itself or of a
+// nearer ancestor
+func (c *Command) inheritedGlobalOptions() []*Option {
+	var inherited []*Option
+	shadowed := func(option *Option) bool {
+		for _, flag := range []string{option.Long, option.Short} {
+			if flag == "" {
+				continue
+			}
+			if c.FindOption(flag) != nil || slices.ContainsFunc(inherited, func(o *Option) bool { return o.Matches(flag) }) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for parent := c.Parent; parent != nil; parent = parent.Parent {
+		for _, option := range parent.Options {
+			if option.Global && !shadowed(option) {
+				inherited = append(inherited, option)
+			}
+		}
+	}
+	return inherited
+}
+
 // GetRequiredOptions returns all required options
 func (c *Command) GetRequiredOptions() []*Option {
 	var required []*Option
@@ -304,7 +419,7 @@ func (c *Command) GetRequiredArguments() []*Argument {
 
 // IsExecutable returns true if the command has an action handler
 func (c *Command) IsExecutable() bool {
-	return c.Action != nil
+	return c.hasActionHandler()
 }
 
 // GetCommandPath returns the full path to this command
@@ -432,6 +547,10 @@ func (c *Command) CopyInheritedSettings(parent *Command) *Command {
 	c.PassThroughOptions = parent.PassThroughOptions
 	c.StoreOptionsAsProperties = parent.StoreOptionsAsProperties
 	c.CombineFlagAndOptionalValue = parent.CombineFlagAndOptionalValue
+	c.AllowAbbreviations = parent.AllowAbbreviations
+	c.StopAtFirstPositional = parent.StopAtFirstPositional
+	c.StopAtFirstUnknown = parent.StopAtFirstUnknown
+	c.CollectErrors = parent.CollectErrors
 	c.ShowHelpAfterError = parent.ShowHelpAfterError
 	c.ShowSuggestionAfterError = parent.ShowSuggestionAfterError
 
@@ -439,6 +558,11 @@ func (c *Command) CopyInheritedSettings(parent *Command) *Command {
 	if parent.OutputConfiguration != nil {
 		c.OutputConfiguration = parent.OutputConfiguration
 	}
+	if parent.HelpConfiguration != nil {
+		// Copy the formatter so customising the child leaves the parent alone
+		helpConfiguration := *parent.HelpConfiguration
+		c.HelpConfiguration = &helpConfiguration
+	}
 
 	// Copy exit override
 	c.ExitOverride = parent.ExitOverride
@@ -481,6 +605,24 @@ func (c *Command) AddHook(event HookEvent, handler HookHandler) *Command {
 	return c
 }
 
+// AddContextHook adds a context-aware lifecycle hook to the command
+func (c *Command) AddContextHook(event HookEvent, handler ContextHookHandler) *Command {
+	if c.Hooks == nil {
+		c.Hooks = &LifecycleHooks{}
+	}
+
+	switch event {
+	case HookEventPreAction:
+		c.Hooks.PreActionContext = append(c.Hooks.PreActionContext, handler)
+	case HookEventPostAction:
+		c.Hooks.PostActionContext = append(c.Hooks.PostActionContext, handler)
+	case HookEventPreSubcommand:
+		c.Hooks.PreSubcommandContext = append(c.Hooks.PreSubcommandContext, handler)
+	}
+
+	return c
+}
+
 // RemoveHook removes all hooks of a specific type
 func (c *Command) RemoveHook(event HookEvent) *Command {
 	if c.Hooks == nil {
@@ -490,10 +632,13 @@ func (c *Command) RemoveHook(event HookEvent) *Command {
 	switch event {
 	case HookEventPreAction:
 		c.Hooks.PreAction = make([]HookHandler, 0)
+		c.Hooks.PreActionContext = nil
 	case HookEventPostAction:
 		c.Hooks.PostAction = make([]HookHandler, 0)
+		c.Hooks.PostActionContext = nil
 	case HookEventPreSubcommand:
 		c.Hooks.PreSubcommand = make([]HookHandler, 0)
+		c.Hooks.PreSubcommandContext = nil
 	}
 
 	return c
@@ -505,6 +650,58 @@ func (c *Command) SetAsyncAction(action AsyncActionHandler) *Command {
 	return c
 }
 
+// SetContextAction sets a context-aware action handler for the command
+func (c *Command) SetContextAction(action ContextActionHandler) *Command {
+	c.ContextAction = action
+	return c
+}
+
+// SetContextAsyncAction sets a context-aware async action handler for the command
+func (c *Command) SetContextAsyncAction(action ContextAsyncActionHandler) *Command {
+	c.ContextAsyncAction = action
+	return c
+}
+
+// SetTimeout sets the maximum time the command's hooks and action may run.
+// When dispatching, a parent's timeout also bounds every subcommand below it.
+func (c *Command) SetTimeout(timeout time.Duration) *Command {
+	c.Timeout = timeout
+	return c
+}
+
+// HandleSignals cancels execution when one of the given signals is received,
+// defaulting to SIGINT and SIGTERM
+func (c *Command) HandleSignals(signals ...os.Signal) *Command {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	c.CancelSignals = signals
+	return c
+}
+
+// ExecuteHooksContext executes the context-aware hooks of a specific type followed by the plain ones
+func (c *Command) ExecuteHooksContext(ctx context.Context, event HookEvent, actionCommand *Command) error {
+	if c.Hooks != nil {
+		var hooks []ContextHookHandler
+		switch event {
+		case HookEventPreAction:
+			hooks = c.Hooks.PreActionContext
+		case HookEventPostAction:
+			hooks = c.Hooks.PostActionContext
+		case HookEventPreSubcommand:
+			hooks = c.Hooks.PreSubcommandContext
+		}
+
+		for _, hook := range hooks {
+			if err := hook(ctx, c, actionCommand); err != nil {
+				return fmt.Errorf("%s hook failed: %w", event, err)
+			}
+		}
+	}
+
+	return c.ExecuteHooks(event, actionCommand)
+}
+
 // ExecuteHooks executes all hooks of a specific type
 func (c *Command) ExecuteHooks(event HookEvent, actionCommand *Command) error {
 	if c.Hooks == nil {
@@ -550,23 +747,25 @@ func (c *Command) ExecuteHooks(event HookEvent, actionCommand *Command) error {
 
 // ExecuteWithHooks executes the command with lifecycle hooks
 func (c *Command) ExecuteWithHooks(args []string, opts map[string]any) error {
+	return c.ExecuteWithHooksContext(context.Background(), args, opts)
+}
+
+// ExecuteWithHooksContext executes the command with lifecycle hooks, abandoning
+// an async action once the context is done
+func (c *Command) ExecuteWithHooksContext(ctx context.Context, args []string, opts map[string]any) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
 	// Execute pre-action hooks
-	if err := c.ExecuteHooks(HookEventPreAction, c); err != nil {
+	if err := c.ExecuteHooksContext(ctx, HookEventPreAction, c); err != nil {
 		return err
 	}
 
 	// Execute the main action
-	var actionErr error
-	if c.AsyncAction != nil {
-		// Handle async action
-		errChan := c.AsyncAction(args, opts)
-		actionErr = <-errChan
-	} else if c.Action != nil {
-		actionErr = c.Action(args, opts)
-	}
+	actionErr := c.runAction(ctx, args, opts)
 
 	// Execute post-action hooks (even if action failed)
-	if hookErr := c.ExecuteHooks(HookEventPostAction, c); hookErr != nil {
+	if hookErr := c.ExecuteHooksContext(ctx, HookEventPostAction, c); hookErr != nil {
 		// If both action and hook failed, return combined error
 		if actionErr != nil {
 			return fmt.Errorf("action failed: %v; post-action hook failed: %v", actionErr, hookErr)
@@ -597,6 +796,9 @@ func (c *Command) HasHooks() bool {
 	return len(c.Hooks.PreAction) > 0 ||
 		len(c.Hooks.PostAction) > 0 ||
 		len(c.Hooks.PreSubcommand) > 0 ||
+		len(c.Hooks.PreActionContext) > 0 ||
+		len(c.Hooks.PostActionContext) > 0 ||
+		len(c.Hooks.PreSubcommandContext) > 0 ||
 		c.PreAction != nil ||
 		c.PostAction != nil ||
 		c.PreSubcommand != nil
@@ -610,19 +812,19 @@ func (c *Command) GetHookCount(event HookEvent) int {
 
 	switch event {
 	case HookEventPreAction:
-		count := len(c.Hooks.PreAction)
+		count := len(c.Hooks.PreAction) + len(c.Hooks.PreActionContext)
 		if c.PreAction != nil {
 			count++
 		}
 		return count
 	case HookEventPostAction:
-		count := len(c.Hooks.PostAction)
+		count := len(c.Hooks.PostAction) + len(c.Hooks.PostActionContext)
 		if c.PostAction != nil {
 			count++
 		}
 		return count
 	case HookEventPreSubcommand:
-		count := len(c.Hooks.PreSubcommand)
+		count := len(c.Hooks.PreSubcommand) + len(c.Hooks.PreSubcommandContext)
 		if c.PreSubcommand != nil {
 			count++
 		}
@@ -696,24 +898,21 @@ func (c *Command) GenerateSuggestion(unknownCommand string) string {
 	return c.generateDefaultSuggestion(unknownCommand)
 }
 
-// generateDefaultSuggestion provides basic suggestion logic
+// generateDefaultSuggestion suggests the subcommands closest to the unknown
+// name by edit distance, or lists the available commands
 func (c *Command) generateDefaultSuggestion(unknownCommand string) string {
 	if len(c.Subcommands) == 0 {
 		return ""
 	}
 
-	// Simple similarity check - find commands that start with the same letter
-	for _, sub := range c.Subcommands {
-		if len(sub.Name) > 0 && len(unknownCommand) > 0 &&
-			strings.ToLower(sub.Name[:1]) == strings.ToLower(unknownCommand[:1]) {
-			return fmt.Sprintf("Did you mean '%s'?", sub.Name)
-		}
+	if suggestion := formatSuggestion(suggestSimilar(unknownCommand, c.suggestionCommandNames())); suggestion != "" {
+		return suggestion
 	}
 
 	// If no similar command found, suggest available commands
-	if len(c.Subcommands) <= 3 {
-		names := make([]string, len(c.Subcommands))
-		for i, sub := range c.Subcommands {
+	if visible := c.GetVisibleSubcommands(); len(visible) > 0 && len(visible) <= 3 {
+		names := make([]string, len(visible))
+		for i, sub := range visible {
 			names[i] = sub.Name
 		}
 		return fmt.Sprintf("Available commands: %s", strings.Join(names, ", "))
@@ -729,83 +928,136 @@ func (c *Command) HandleError(err error) {
 		return
 	}
 
+	// Help and version output are not failures, and the help shown for a
+	// missing subcommand has already been written
+	exitCode := 1
+	if cmdErr, ok := AsCommanderError(err); ok {
+		exitCode = cmdErr.ExitCode
+		if exitCode == 0 || cmdErr.Code == "commander.help" {
+			os.Exit(exitCode)
+		}
+	}
+
 	// Default error handling
-	c.OutputError(fmt.Sprintf("Error: %s\n", err.Error()))
+	c.OutputError(errorMessage(err))
 
 	if c.ShowHelpAfterError {
 		c.WriteErr("\n")
 		// Generate and show help
-		c.WriteErr(c.GenerateHelp())
+		c.WriteErr(c.generateErrHelp())
 	}
 
 	// Exit with error code
-	os.Exit(1)
+	os.Exit(exitCode)
 }
 
-// GenerateHelp generates help text for the command
-func (c *Command) GenerateHelp() string {
-	help := fmt.Sprintf("Usage: %s", c.GetFullName())
-
-	// Add options placeholder
-	if len(c.Options) > 0 {
-		help += " [options]"
+// errorMessage formats err for HandleError, listing the errors collected in
+// an AggregateError one per line
+func errorMessage(err error) string {
+	var aggregate *AggregateError
+	if !errors.As(err, &aggregate) {
+		return fmt.Sprintf("Error: %s\n", err.Error())
 	}
 
-	// Add arguments
-	for _, arg := range c.Arguments {
-		if arg.Required {
-			if arg.Variadic {
-				help += fmt.Sprintf(" <%s...>", arg.Name)
-			} else {
-				help += fmt.Sprintf(" <%s>", arg.Name)
-			}
-		} else {
-			if arg.Variadic {
-				help += fmt.Sprintf(" [%s...]", arg.Name)
-			} else {
-				help += fmt.Sprintf(" [%s]", arg.Name)
-			}
-		}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Error: %d problems found:\n", len(aggregate.Errors))
+	for _, e := range aggregate.Errors {
+		fmt.Fprintf(&b, "  - %s\n", strings.ReplaceAll(e.Error(), "\n", "\n    "))
 	}
+	return b.String()
+}
 
-	// Add subcommands placeholder
-	if len(c.Subcommands) > 0 {
-		help += " [command]"
-	}
+// GenerateHelp generates help text for the command using its Help formatter
+func (c *Command) GenerateHelp() string {
+	return c.CreateHelp().FormatHelp(c)
+}
 
-	help += "\n"
+// generateErrHelp generates help text sized for the error output
+func (c *Command) generateErrHelp() string {
+	return c.createHelp(true).FormatHelp(c)
+}
 
-	if c.Description != "" {
-		help += fmt.Sprintf("\n%s\n", c.Description)
+// OutputHelp writes the command's help text using the configured output writer
+func (c *Command) OutputHelp() {
+	c.WriteOut(c.GenerateHelp())
+}
+
+// SetVersion sets the version and registers the -V, --version option
+func (c *Command) SetVersion(version string) *Command {
+	c.Version = version
+	c.ensureVersionOption()
+	return c
+}
+
+// SetVersionOption sets the version and registers it under custom flags
+func (c *Command) SetVersionOption(version, flags, description string) *Command {
+	if c.VersionOption != nil {
+		c.RemoveOption(c.VersionOption)
 	}
+	c.Version = version
+	c.VersionOption = NewBooleanOption(flags, description)
+	c.AddOption(c.VersionOption)
+	return c
+}
 
-	// Add options help
-	if len(c.Options) > 0 {
-		help += "\nOptions:\n"
-		for _, opt := range c.Options {
-			if !opt.Hidden {
-				help += fmt.Sprintf("  %s  %s\n", opt.Flags, opt.Description)
-			}
-		}
+// ensureVersionOption registers the default version option when a version is set,
+// falling back to --version alone if -V is already taken
+func (c *Command) ensureVersionOption() {
+	if c.Version == "" || c.VersionOption != nil || c.FindOption("version") != nil {
+		return
 	}
 
-	// Add arguments help
-	if len(c.Arguments) > 0 {
-		help += "\nArguments:\n"
-		for _, arg := range c.Arguments {
-			help += fmt.Sprintf("  %s  %s\n", arg.Name, arg.Description)
-		}
+	flags := "-V, --version"
+	if c.FindOption("V") != nil {
+		flags = "--version"
 	}
+	c.VersionOption = NewBooleanOption(flags, "output the version number")
+	c.AddOption(c.VersionOption)
+}
 
-	// Add subcommands help
-	if len(c.Subcommands) > 0 {
-		help += "\nCommands:\n"
-		for _, sub := range c.Subcommands {
-			if !sub.Hidden {
-				help += fmt.Sprintf("  %s  %s\n", sub.Name, sub.Description)
-			}
-		}
+// RemoveOption removes an option from the command
+func (c *Command) RemoveOption(option *Option) *Command {
+	c.Options = slices.DeleteFunc(c.Options, func(o *Option) bool {
+		return o == option
+	})
+	return c
+}
+
+// SetHelpCommand replaces the implicit help command, or disables it when nil
+func (c *Command) SetHelpCommand(helpCommand *Command) *Command {
+	c.HelpCommand = helpCommand
+	c.DisableHelpCommand = helpCommand == nil
+	return c
+}
+
+// GetHelpCommand returns the help command for this command. Commands with
+// subcommands and no action get an implicit "help [command]" subcommand
+// unless it has been disabled or a real "help" subcommand exists.
+func (c *Command) GetHelpCommand() *Command {
+	if c.HelpCommand != nil {
+		return c.HelpCommand
 	}
+	if c.DisableHelpCommand || !c.HasSubcommands() || c.hasActionHandler() {
+		return nil
+	}
+	if c.FindSubcommandByNameOrAlias("help") != nil {
+		return nil
+	}
+
+	helpCmd := NewCommand("help")
+	helpCmd.Description = "display help for command"
+	helpCmd.AddArgument(NewArgument("[command]", "command to show help for"))
+	return helpCmd
+}
+
+// helpDisplayed writes this command's help and returns the matching error
+func (c *Command) helpDisplayed() error {
+	c.OutputHelp()
+	return NewHelpDisplayedError()
+}
 
-	return help
+// versionDisplayed writes this command's version and returns the matching error
+func (c *Command) versionDisplayed() error {
+	c.WriteOut(c.Version + "\n")
+	return NewVersionDisplayedError()
 }
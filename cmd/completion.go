@@ -0,0 +1,419 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// CompleteCommandName is the hidden subcommand the completion scripts call
+// back into to request candidates for the word under the cursor
+const CompleteCommandName = "__complete"
+
+// CompletionDirective tells the shell script what to do after printing the
+// candidates returned by the __complete command
+type CompletionDirective int
+
+const (
+	// CompletionDirectiveDefault offers only the returned candidates
+	CompletionDirectiveDefault CompletionDirective = iota
+	// CompletionDirectiveFiles also offers file names from the shell
+	CompletionDirectiveFiles
+	// CompletionDirectiveDirectories also offers directory names from the shell
+	CompletionDirectiveDirectories
+)
+
+// CompletionFunc returns candidate values for the word being completed
+type CompletionFunc func(toComplete string) []string
+
+// Completer describes how shell completion suggests values for an option or
+// argument
+type Completer struct {
+	Directive CompletionDirective
+	Func      CompletionFunc
+}
+
+// FileCompleter completes file names
+func FileCompleter() *Completer {
+	return &Completer{Directive: CompletionDirectiveFiles}
+}
+
+// DirectoryCompleter completes directory names only
+func DirectoryCompleter() *Completer {
+	return &Completer{Directive: CompletionDirectiveDirectories}
+}
+
+// FuncCompleter completes with the values returned by fn
+func FuncCompleter(fn CompletionFunc) *Completer {
+	return &Completer{Func: fn}
+}
+
+// completionShells lists the shells GenerateCompletion supports
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// EnableCompletion adds a `completion <shell>` subcommand that prints the
+// completion script and the hidden __complete subcommand the script calls
+func (c *Command) EnableCompletion() *Command {
+	if c.FindSubcommand("completion") == nil {
+		completion := NewCommand("completion")
+		completion.Description = "generate shell completion script"
+		completion.AddArgument(NewArgument("<shell>", "shell to generate for").SetChoices(completionShells))
+		completion.CopyInheritedSettings(c)
+		completion.SetAction(func(args []string, opts map[string]any) error {
+			script, err := c.GenerateCompletion(args[0])
+			if err != nil {
+				return err
+			}
+			c.WriteOut(script)
+			return nil
+		})
+		c.AddSubcommand(completion)
+	}
+
+	if c.FindSubcommand(CompleteCommandName) == nil {
+		complete := NewCommand(CompleteCommandName)
+		complete.Hidden = true
+		complete.AllowUnknownOption = true
+		complete.AllowExcessArguments = true
+		complete.CopyInheritedSettings(c)
+		complete.SetAction(func(args []string, opts map[string]any) error {
+			c.writeCompletions(args)
+			return nil
+		})
+		c.AddSubcommand(complete)
+	}
+	return c
+}
+
+// GenerateCompletion returns the completion script for the given shell
+// (bash, zsh, fish or powershell). The script completes the root command.
+func (c *Command) GenerateCompletion(shell string) (string, error) {
+	root := c
+	for root.Parent != nil {
+		root = root.Parent
+	}
+
+	program := root.Name
+	funcName := "__" + regexp.MustCompile(`[^A-Za-z0-9_]`).ReplaceAllString(program, "_") + "_complete"
+
+	switch strings.ToLower(shell) {
+	case "bash":
+		return fmt.Sprintf(bashCompletionTemplate, program, funcName), nil
+	case "zsh":
+		return fmt.Sprintf(zshCompletionTemplate, program, funcName), nil
+	case "fish":
+		return fmt.Sprintf(fishCompletionTemplate, program, funcName), nil
+	case "powershell", "pwsh":
+		return fmt.Sprintf(powershellCompletionTemplate, program), nil
+	default:
+		return "", fmt.Errorf("unsupported shell '%s', expected one of: %s", shell, strings.Join(completionShells, ", "))
+	}
+}
+
+// writeCompletions prints the candidates for the command line words, one per
+// line with an optional tab-separated description, followed by ":<directive>"
+func (c *Command) writeCompletions(words []string) {
+	candidates, directive := c.Complete(words)
+	var out strings.Builder
+	for _, candidate := range candidates {
+		out.WriteString(candidate)
+		out.WriteString("\n")
+	}
+	fmt.Fprintf(&out, ":%d\n", directive)
+	c.WriteOut(out.String())
+}
+
+// Complete returns completion candidates for the last of the given words,
+// which are the command line words after the program name. Candidates may
+// carry a description after a tab.
+func (c *Command) Complete(words []string) ([]string, CompletionDirective) {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	toComplete := words[len(words)-1]
+	// PowerShell drops empty native arguments, so its script passes "" instead
+	if toComplete == `""` {
+		toComplete = ""
+	}
+
+	cmd := c
+	argIndex := 0
+	afterDoubleDash := false
+	var pending *Option
+
+	for _, word := range words[:len(words)-1] {
+		if pending != nil {
+			pending = nil
+			continue
+		}
+		if word == "--" && !afterDoubleDash {
+			afterDoubleDash = true
+			continue
+		}
+		if !afterDoubleDash && len(word) > 1 && strings.HasPrefix(word, "-") {
+			if opt := completionFindOption(cmd, word); opt != nil && takesValue(opt) && !strings.Contains(word, "=") {
+				pending = opt
+			}
+			continue
+		}
+		if argIndex == 0 && !afterDoubleDash {
+			if sub := cmd.FindSubcommand(word); sub != nil {
+				cmd = sub
+				continue
+			}
+		}
+		argIndex++
+	}
+
+	if pending != nil {
+		return completeValue(pending.Completer, pending.Choices, toComplete)
+	}
+
+	if !afterDoubleDash && strings.HasPrefix(toComplete, "-") {
+		if flag, value, found := strings.Cut(toComplete, "="); found {
+			opt := completionFindOption(cmd, flag)
+			if opt == nil {
+				return nil, CompletionDirectiveDefault
+			}
+			candidates, directive := completeValue(opt.Completer, opt.Choices, value)
+			for i, candidate := range candidates {
+				candidates[i] = flag + "=" + candidate
+			}
+			return candidates, directive
+		}
+		return completeOptionNames(cmd, toComplete), CompletionDirectiveDefault
+	}
+
+	var candidates []string
+	if argIndex == 0 && !afterDoubleDash {
+		for _, sub := range cmd.Subcommands {
+			if sub.Hidden {
+				continue
+			}
+			for _, name := range append([]string{sub.Name}, sub.Aliases...) {
+				if strings.HasPrefix(name, toComplete) {
+					candidates = append(candidates, withDescription(name, sub.Description))
+				}
+			}
+		}
+		if helpCmd := cmd.GetHelpCommand(); helpCmd != nil && !helpCmd.Hidden && cmd.FindSubcommand(helpCmd.Name) == nil &&
+			strings.HasPrefix(helpCmd.Name, toComplete) {
+			candidates = append(candidates, withDescription(helpCmd.Name, helpCmd.Description))
+		}
+	}
+
+	directive := CompletionDirectiveDefault
+	if arg := completionArgument(cmd, argIndex); arg != nil {
+		var values []string
+		values, directive = completeValue(arg.Completer, arg.Choices, toComplete)
+		candidates = append(candidates, values...)
+	}
+	return candidates, directive
+}
+
+// completionFindOption finds the option named by a command line word such as
+// "--name", "--name=value" or "-n"
+func completionFindOption(cmd *Command, word string) *Option {
+	flag, _, _ := strings.Cut(word, "=")
+	flag = strings.TrimLeft(flag, "-")
+	if flag == "" {
+		return nil
+	}
+	if opt := cmd.FindOption(flag); opt != nil {
+		return opt
+	}
+	if opt := cmd.findGlobalOption(flag); opt != nil {
+		return opt
+	}
+	// Short flags may be combined, e.g. -vf; the last one may take a value
+	if !strings.HasPrefix(word, "--") && len(flag) > 1 {
+		last := flag[len(flag)-1:]
+		if opt := cmd.FindOption(last); opt != nil {
+			return opt
+		}
+		return cmd.findGlobalOption(last)
+	}
+	return nil
+}
+
+// takesValue reports whether the option declares a value, through its flags or
+// a value type, so the next word on the command line belongs to it
+func takesValue(opt *Option) bool {
+	return opt.expectsValue() && (strings.ContainsAny(opt.Flags, "<[") || opt.TypeName != "")
+}
+
+// completeOptionNames returns the visible flags of cmd starting with prefix
+func completeOptionNames(cmd *Command, prefix string) []string {
+	var candidates []string
+	for _, opt := range append(slices.Clone(cmd.Options), cmd.inheritedGlobalOptions()...) {
+		if opt.Hidden {
+			continue
+		}
+		flags := opt.longFlags()
+		// Only offer short flags once the user has typed a single dash
+		if opt.Short != "" && (prefix == "-" || len(flags) == 0) {
+			flags = append(flags, "-"+opt.Short)
+		}
+		for _, flag := range flags {
+			if strings.HasPrefix(flag, prefix) {
+				candidates = append(candidates, withDescription(flag, opt.Description))
+			}
+		}
+	}
+	return candidates
+}
+
+// completionArgument returns the argument receiving the positional at index,
+// with a trailing variadic argument taking every remaining position
+func completionArgument(cmd *Command, index int) *Argument {
+	if index < len(cmd.Arguments) {
+		return cmd.Arguments[index]
+	}
+	if n := len(cmd.Arguments); n > 0 && cmd.Arguments[n-1].Variadic {
+		return cmd.Arguments[n-1]
+	}
+	return nil
+}
+
+// completeValue completes a value for an option or argument. An explicit
+// completer wins over choices.
+func completeValue(completer *Completer, choices []string, toComplete string) ([]string, CompletionDirective) {
+	var candidates []string
+	directive := CompletionDirectiveDefault
+	switch {
+	case completer != nil:
+		directive = completer.Directive
+		if completer.Func != nil {
+			candidates = completer.Func(toComplete)
+		}
+	case len(choices) > 0:
+		candidates = choices
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, toComplete) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches, directive
+}
+
+// withDescription appends a tab-separated description to a candidate
+func withDescription(candidate, description string) string {
+	if description == "" {
+		return candidate
+	}
+	return candidate + "\t" + strings.ReplaceAll(description, "\n", " ")
+}
+
+// isCompletionCommand reports whether args invoke the __complete command,
+// whose words must reach Complete without being parsed as options
+func (c *Command) isCompletionCommand(args []string) bool {
+	if len(args) == 0 || args[0] != CompleteCommandName {
+		return false
+	}
+	return slices.ContainsFunc(c.Subcommands, func(sub *Command) bool {
+		return sub.Name == CompleteCommandName
+	})
+}
+
+const bashCompletionTemplate = `# bash completion for %[1]s
+%[2]s() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local out directive line
+    out=$(%[1]s __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null) || return
+    directive=${out##*:}
+    out=${out%%:*}
+
+    COMPREPLY=()
+    while IFS= read -r line; do
+        [[ -n "$line" ]] && COMPREPLY+=("${line%%%%$'\t'*}")
+    done <<< "$out"
+
+    if [[ "$directive" == 1 ]]; then
+        COMPREPLY+=($(compgen -f -- "$cur"))
+    elif [[ "$directive" == 2 ]]; then
+        COMPREPLY+=($(compgen -d -- "$cur"))
+    fi
+}
+complete -o filenames -F %[2]s %[1]s
+`
+
+const zshCompletionTemplate = `#compdef %[1]s
+%[2]s() {
+    local -a completions
+    local out directive line name desc
+    out=$(%[1]s __complete "${(@)words[2,CURRENT]}" 2>/dev/null) || return
+    directive=${out##*:}
+    out=${out%%:*}
+
+    for line in "${(@f)out}"; do
+        [[ -z "$line" ]] && continue
+        name=${line%%%%$'\t'*}
+        desc=
+        [[ "$line" == *$'\t'* ]] && desc=${line#*$'\t'}
+        completions+=("${name//:/\\:}${desc:+:$desc}")
+    done
+
+    (( ${#completions} )) && _describe 'values' completions
+    if [[ "$directive" == 1 ]]; then
+        _files
+    elif [[ "$directive" == 2 ]]; then
+        _files -/
+    fi
+}
+compdef %[2]s %[1]s
+`
+
+const fishCompletionTemplate = `# fish completion for %[1]s
+function %[2]s
+    set -l current (commandline -ct)
+    set -l tokens (commandline -opc) "$current"
+    set -e tokens[1]
+    set -l out (%[1]s __complete $tokens 2>/dev/null)
+    test (count $out) -gt 0; or return
+    set -l directive (string replace ':' '' -- $out[-1])
+    set -e out[-1]
+
+    for line in $out
+        echo $line
+    end
+    if test "$directive" = 1
+        __fish_complete_path "$current"
+    else if test "$directive" = 2
+        __fish_complete_directories "$current"
+    end
+end
+complete -c %[1]s -f -a '(%[2]s)'
+`
+
+const powershellCompletionTemplate = `# powershell completion for %[1]s
+Register-ArgumentCompleter -Native -CommandName '%[1]s' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 |
+        Where-Object { $_.Extent.StartOffset -lt $cursorPosition } |
+        ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') { $words += '""' }
+
+    $out = @(& '%[1]s' __complete @words 2>$null)
+    if ($out.Count -eq 0) { return }
+    $directive = $out[-1].TrimStart(':')
+    $lines = if ($out.Count -gt 1) { $out[0..($out.Count - 2)] } else { @() }
+
+    foreach ($line in $lines) {
+        $parts = $line -split "` + "`" + `t", 2
+        $tooltip = if ($parts.Count -gt 1 -and $parts[1]) { $parts[1] } else { $parts[0] }
+        [System.Management.Automation.CompletionResult]::new($parts[0], $parts[0], 'ParameterValue', $tooltip)
+    }
+
+    if ($directive -eq '1' -or $directive -eq '2') {
+        Get-ChildItem -Path "$wordToComplete*" -Directory:($directive -eq '2') -ErrorAction SilentlyContinue |
+            ForEach-Object {
+                [System.Management.Automation.CompletionResult]::new($_.Name, $_.Name, 'ProviderItem', $_.FullName)
+            }
+    }
+}
+`
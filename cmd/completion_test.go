@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newCompletionTestCommand() *Command {
+	root := NewCommand("app")
+	root.AddOption(NewBooleanOption("-v, --verbose", "verbose output"))
+	root.AddOption(NewOption("--secret <token>", "api token").SetHidden(true))
+
+	deploy := NewCommand("deploy")
+	deploy.Description = "deploy a service"
+	deploy.AddAlias("d")
+	deploy.AddOption(CreateChoiceOption("-e, --env <name>", "target environment", []string{"dev", "staging", "prod"}))
+	deploy.AddOption(NewOption("--manifest <file>", "manifest file").SetFileValidator())
+	deploy.AddArgument(NewArgument("<service>", "service to deploy").SetCompleter(FuncCompleter(func(string) []string {
+		return []string{"api", "web", "worker"}
+	})))
+	deploy.AddArgument(NewArgument("[dirs...]", "working directories").SetDirectoryValidator())
+	root.AddSubcommand(deploy)
+
+	internal := NewCommand("internal")
+	internal.Hidden = true
+	root.AddSubcommand(internal)
+
+	root.EnableCompletion()
+	return root
+}
+
+func TestComplete(t *testing.T) {
+	root := newCompletionTestCommand()
+
+	tests := []struct {
+		name      string
+		words     []string
+		expected  []string
+		directive CompletionDirective
+	}{
+		{"subcommands", []string{""}, []string{"deploy\tdeploy a service", "d\tdeploy a service", "completion\tgenerate shell completion script", "help\tdisplay help for command"}, CompletionDirectiveDefault},
+		{"subcommand prefix", []string{"de"}, []string{"deploy\tdeploy a service"}, CompletionDirectiveDefault},
+		{"long options", []string{"--v"}, []string{"--verbose\tverbose output"}, CompletionDirectiveDefault},
+		{"short options", []string{"-"}, []string{"--help\tdisplay help for command", "-h\tdisplay help for command", "--verbose\tverbose output", "-v\tverbose output"}, CompletionDirectiveDefault},
+		{"option choices", []string{"deploy", "--env", "p"}, []string{"prod"}, CompletionDirectiveDefault},
+		{"inline option choices", []string{"d", "--env=s"}, []string{"--env=staging"}, CompletionDirectiveDefault},
+		{"argument completer", []string{"-v", "deploy", "-e", "dev", "w"}, []string{"web", "worker"}, CompletionDirectiveDefault},
+		{"file validator hint", []string{"deploy", "--manifest", ""}, nil, CompletionDirectiveFiles},
+		{"directory validator hint", []string{"deploy", "api", "src", ""}, nil, CompletionDirectiveDirectories},
+		{"shell choices", []string{"completion", "f"}, []string{"fish"}, CompletionDirectiveDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, directive := root.Complete(tt.words)
+			if !reflect.DeepEqual(candidates, tt.expected) {
+				t.Errorf("Expected candidates %q, got %q", tt.expected, candidates)
+			}
+			if directive != tt.directive {
+				t.Errorf("Expected directive %d, got %d", tt.directive, directive)
+			}
+		})
+	}
+}
+
+func TestCompleteCommandOutput(t *testing.T) {
+	var out strings.Builder
+	root := newCompletionTestCommand()
+	root.ConfigureOutput(&OutputConfiguration{WriteOut: func(s string) { out.WriteString(s) }})
+
+	// Raw words such as --help must reach the completer rather than the parser
+	if err := root.Execute([]string{CompleteCommandName, "deploy", "--help", "--env", ""}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if out.String() != "dev\nstaging\nprod\n:0\n" {
+		t.Errorf("Unexpected completion output %q", out.String())
+	}
+
+	if strings.Contains(root.GenerateHelp(), CompleteCommandName) {
+		t.Error("The __complete command should be hidden from help")
+	}
+}
+
+func TestGenerateCompletion(t *testing.T) {
+	var out strings.Builder
+	root := newCompletionTestCommand()
+	root.ConfigureOutput(&OutputConfiguration{WriteOut: func(s string) { out.WriteString(s) }})
+
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		out.Reset()
+		if err := root.Execute([]string{"completion", shell}); err != nil {
+			t.Fatalf("Unexpected error for %s: %v", shell, err)
+		}
+		script := out.String()
+		if !strings.Contains(script, "app __complete") && !strings.Contains(script, "'app' __complete") {
+			t.Errorf("Expected %s script to call back into __complete, got:\n%s", shell, script)
+		}
+		if strings.Contains(script, "%!") {
+			t.Errorf("Malformed %s script:\n%s", shell, script)
+		}
+	}
+
+	if _, err := root.GenerateCompletion("tcsh"); err == nil {
+		t.Error("Expected error for unsupported shell")
+	}
+}
+
+func TestValidatorRunsOnParse(t *testing.T) {
+	root := NewCommand("app")
+	root.AddArgument(NewArgument("<dir>", "directory").SetValidator(ValidateDirectoryExists))
+
+	if _, err := NewParser().ParseCommand(root, []string{t.TempDir()}); err != nil {
+		t.Errorf("Unexpected error for existing directory: %v", err)
+	}
+	if _, err := NewParser().ParseCommand(root, []string{"/does/not/exist"}); err == nil {
+		t.Error("Expected validator error for missing directory")
+	}
+
+	// Test that a non-variadic option validates its whole parsed value
+	pair := NewOption("--pair <value>", "pair").SetParser(func(value string, previous any) (any, error) {
+		var items []any
+		for _, item := range strings.Split(value, ",") {
+			items = append(items, item)
+		}
+		return items, nil
+	})
+	var validated any
+	pair.SetValidator(func(value any) error {
+		validated = value
+		if items, ok := value.([]any); !ok || len(items) != 2 {
+			return fmt.Errorf("expected two values")
+		}
+		return nil
+	})
+	if _, err := pair.ParseValue("a,b", nil); err != nil || !reflect.DeepEqual(validated, []any{"a", "b"}) {
+		t.Errorf("Expected the validator to see [a b], got %v (%v)", validated, err)
+	}
+	if _, err := pair.ParseValue("a", nil); err == nil || !strings.Contains(err.Error(), "invalid value for option '--pair <value>'") {
+		t.Errorf("Expected a wrapped validator error, got %v", err)
+	}
+}
@@ -0,0 +1,535 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConfigDecoder decodes the contents of a configuration file into nested maps.
+// Nested maps are sections; sections named after subcommands hold their options.
+type ConfigDecoder func(data []byte) (map[string]any, error)
+
+// defaultConfigDecoders maps file extensions to the built-in decoders.
+// YAML has no stdlib decoder, so ".yaml" files need AddConfigDecoder.
+var defaultConfigDecoders = map[string]ConfigDecoder{
+	".json": DecodeJSONConfig,
+	".toml": DecodeTOMLConfig,
+	".ini":  DecodeINIConfig,
+	".cfg":  DecodeINIConfig,
+	".conf": DecodeINIConfig,
+}
+
+// SetConfigOption registers the option that names a configuration file, e.g.
+// "-c, --config <path>". Values from the file are applied with precedence
+// cli > env > config > default.
+func (c *Command) SetConfigOption(flags, description string) *Command {
+	if c.ConfigOption != nil {
+		c.RemoveOption(c.ConfigOption)
+	}
+	c.ConfigOption = NewOption(flags, description)
+	c.AddOption(c.ConfigOption)
+	return c
+}
+
+// AddConfigDecoder registers a decoder for configuration files with the given
+// extension, overriding any built-in decoder for it
+func (c *Command) AddConfigDecoder(extension string, decoder ConfigDecoder) *Command {
+	if c.ConfigDecoders == nil {
+		c.ConfigDecoders = make(map[string]ConfigDecoder)
+	}
+	c.ConfigDecoders[normalizeConfigExtension(extension)] = decoder
+	return c
+}
+
+// LoadConfig reads and decodes a configuration file, choosing the decoder by
+// file extension from this command, its ancestors, then the built-in decoders.
+// Failures are reported as a *ConfigError carrying the path.
+func (c *Command) LoadConfig(path string) (map[string]any, error) {
+	extension := normalizeConfigExtension(filepath.Ext(path))
+	decoder := c.findConfigDecoder(extension)
+	if decoder == nil {
+		return nil, NewConfigError(
+			fmt.Sprintf("no config decoder registered for '%s' files: %s", extension, path), path, "", nil)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, NewConfigError(fmt.Sprintf("failed to read config file: %v", err), path, "", err)
+	}
+
+	values, err := decoder(data)
+	if err != nil {
+		return nil, NewConfigError(fmt.Sprintf("failed to decode config file %s: %v", path, err), path, "", err)
+	}
+	return values, nil
+}
+
+// findConfigDecoder looks up a decoder for the extension up the command chain
+func (c *Command) findConfigDecoder(extension string) ConfigDecoder {
+	for current := c; current != nil; current = current.Parent {
+		if decoder, exists := current.ConfigDecoders[extension]; exists {
+			return decoder
+		}
+	}
+	return defaultConfigDecoders[extension]
+}
+
+// normalizeConfigExtension lowercases an extension and ensures the leading dot
+func normalizeConfigExtension(extension string) string {
+	extension = strings.ToLower(extension)
+	if !strings.HasPrefix(extension, ".") {
+		extension = "." + extension
+	}
+	return extension
+}
+
+// applyConfigOptions fills options that are unset or still at their default from
+// the configuration file named by the nearest command's config option
+func (p *Parser) applyConfigOptions(cmd *Command, result *ParsedCommand) error {
+	path, owner := p.findConfigPath(result)
+	if path == "" {
+		return nil
+	}
+
+	if p.configPath != path {
+		values, err := owner.LoadConfig(path)
+		if err != nil {
+			return err
+		}
+		p.configPath = path
+		p.configValues = values
+	}
+
+	section := configSection(p.configValues, cmd)
+	if section == nil {
+		return nil
+	}
+
+	for _, option := range cmd.Options {
+		if option == cmd.HelpOption || option == cmd.VersionOption || option == cmd.ConfigOption {
+			continue
+		}
+
+		key := p.getOptionKey(option)
+		switch result.GetOptionValueSource(key) {
+		case "", ValueSourceDefault:
+		default:
+			continue
+		}
+
+		raw, found := lookupConfigValue(section, option)
+		if !found {
+			continue
+		}
+
+		value, err := option.configValue(raw)
+		if err != nil {
+			return NewConfigError(
+				fmt.Sprintf("invalid value for option %s in config file %s: %v", option.Flags, path, err),
+				path, option.displayName(false), err)
+		}
+		result.setOption(key, value, ValueSourceConfig)
+	}
+
+	return nil
+}
+
+// findConfigPath returns the config file path set on this command or the nearest ancestor
+func (p *Parser) findConfigPath(result *ParsedCommand) (string, *Command) {
+	for current := result; current != nil; current = current.Parent {
+		option := current.Command.ConfigOption
+		if option == nil {
+			continue
+		}
+		if path, ok := current.Options[p.getOptionKey(option)].(string); ok && path != "" {
+			return path, current.Command
+		}
+	}
+	return "", nil
+}
+
+// configSection returns the section of the config that applies to cmd: the top
+// level for the root command and nested sections along the subcommand path
+func configSection(values map[string]any, cmd *Command) map[string]any {
+	section := values
+	for _, command := range cmd.GetCommandPath()[1:] {
+		next, ok := section[command.Name].(map[string]any)
+		if !ok {
+			return nil
+		}
+		section = next
+	}
+	return section
+}
+
+// lookupConfigValue finds the config entry for an option, matching the long flag
+// exactly first and then ignoring case, dashes and underscores (dry-run, dry_run, dryRun)
+func lookupConfigValue(section map[string]any, option *Option) (any, bool) {
+	name := option.Long
+	if name == "" {
+		name = option.Short
+	}
+
+	if value, exists := section[name]; exists {
+		if _, isSection := value.(map[string]any); !isSection {
+			return value, true
+		}
+	}
+
+	normalized := normalizeConfigKey(name)
+	for key, value := range section {
+		if _, isSection := value.(map[string]any); isSection {
+			continue
+		}
+		if normalizeConfigKey(key) == normalized {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// normalizeConfigKey lowercases a key and strips dashes and underscores
+func normalizeConfigKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(key))
+}
+
+// configValue converts a decoded config value through the option's parser and choices
+func (o *Option) configValue(raw any) (any, error) {
+	if o.Type == OptionTypeBoolean {
+		if value, ok := raw.(bool); ok {
+			return value, nil
+		}
+		return DefaultBoolParser(configString(raw), nil)
+	}
+
+	// Count options take a number of uses; true counts as one use
+	if o.Type == OptionTypeCount {
+		if value, ok := raw.(bool); ok {
+			if value {
+				return 1, nil
+			}
+			return 0, nil
+		}
+		count, err := strconv.Atoi(configString(raw))
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("expected a non-negative count, got %v", raw)
+		}
+		return count, nil
+	}
+
+	if items, ok := raw.([]any); ok {
+		if !o.Variadic {
+			return nil, fmt.Errorf("expected a single value, got a list")
+		}
+		var value any
+		for _, item := range items {
+			parsed, err := o.ParseValue(configString(item), value)
+			if err != nil {
+				return nil, err
+			}
+			value = parsed
+		}
+		return value, nil
+	}
+
+	return o.ParseValue(configString(raw), nil)
+}
+
+// configString formats a decoded scalar the way it would appear on the command line
+func configString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// DecodeJSONConfig decodes a JSON object
+func DecodeJSONConfig(data []byte) (map[string]any, error) {
+	values := make(map[string]any)
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// DecodeINIConfig decodes INI files: "key = value" or "key: value" pairs,
+// "[section]" headers with dots for nesting, and ";" or "#" comments.
+// Values are strings; a repeated key becomes a list.
+func DecodeINIConfig(data []byte) (map[string]any, error) {
+	root := make(map[string]any)
+	section := root
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header", i+1)
+			}
+			var err error
+			section, err = configTable(root, strings.Split(strings.TrimSpace(line[1:len(line)-1]), "."))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			continue
+		}
+
+		idx := strings.IndexAny(line, "=:")
+		if idx <= 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", i+1)
+		}
+		key := strings.TrimSpace(line[:idx])
+		value := unquoteINIValue(strings.TrimSpace(line[idx+1:]))
+
+		switch existing := section[key].(type) {
+		case nil:
+			section[key] = value
+		case []any:
+			section[key] = append(existing, value)
+		default:
+			section[key] = []any{existing, value}
+		}
+	}
+
+	return root, nil
+}
+
+// unquoteINIValue strips matching single or double quotes
+func unquoteINIValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// DecodeTOMLConfig decodes a subset of TOML: tables and dotted table names,
+// dotted keys, basic and literal strings, integers, floats, booleans and
+// (possibly multi-line) arrays of those. Arrays of tables, inline tables and
+// dates are not supported.
+func DecodeTOMLConfig(data []byte) (map[string]any, error) {
+	root := make(map[string]any)
+	table := root
+
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimSpace(stripTOMLComment(lines[i]))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[[") {
+			return nil, fmt.Errorf("line %d: arrays of tables are not supported", lineNumber)
+		}
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated table header", lineNumber)
+			}
+			var err error
+			table, err = configTable(root, splitTOMLKey(line[1:len(line)-1]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			continue
+		}
+
+		idx := strings.Index(line, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNumber)
+		}
+		keyPath := splitTOMLKey(line[:idx])
+		rawValue := strings.TrimSpace(line[idx+1:])
+
+		// Multi-line arrays continue until the brackets balance
+		for tomlBracketDepth(rawValue) > 0 && i+1 < len(lines) {
+			i++
+			rawValue += " " + strings.TrimSpace(stripTOMLComment(lines[i]))
+		}
+
+		value, err := parseTOMLValue(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+
+		parent, err := configTable(table, keyPath[:len(keyPath)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		key := keyPath[len(keyPath)-1]
+		if _, exists := parent[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key '%s'", lineNumber, key)
+		}
+		parent[key] = value
+	}
+
+	return root, nil
+}
+
+// configTable returns the nested map at path, creating intermediate maps
+func configTable(root map[string]any, path []string) (map[string]any, error) {
+	table := root
+	for _, name := range path {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("empty section name")
+		}
+		switch existing := table[name].(type) {
+		case nil:
+			next := make(map[string]any)
+			table[name] = next
+			table = next
+		case map[string]any:
+			table = existing
+		default:
+			return nil, fmt.Errorf("'%s' is already defined as a value", name)
+		}
+	}
+	return table, nil
+}
+
+// splitTOMLKey splits a dotted key, removing quotes from quoted parts
+func splitTOMLKey(key string) []string {
+	parts := strings.Split(strings.TrimSpace(key), ".")
+	for i, part := range parts {
+		parts[i] = unquoteINIValue(strings.TrimSpace(part))
+	}
+	return parts
+}
+
+// stripTOMLComment removes a trailing # comment that is not inside a string
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch ch := line[i]; {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// tomlBracketDepth returns the number of unclosed array brackets outside strings
+func tomlBracketDepth(value string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(value); i++ {
+		switch ch := value[i]; {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '[':
+			depth++
+		case ch == ']':
+			depth--
+		}
+	}
+	return depth
+}
+
+// parseTOMLValue parses a single TOML value
+func parseTOMLValue(value string) (any, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return nil, fmt.Errorf("missing value")
+	case value[0] == '"':
+		return strconv.Unquote(value)
+	case value[0] == '\'':
+		if len(value) < 2 || value[len(value)-1] != '\'' {
+			return nil, fmt.Errorf("unterminated string %s", value)
+		}
+		return value[1 : len(value)-1], nil
+	case value[0] == '[':
+		return parseTOMLArray(value)
+	case value == "true":
+		return true, nil
+	case value == "false":
+		return false, nil
+	}
+
+	number := strings.ReplaceAll(value, "_", "")
+	if intValue, err := strconv.ParseInt(number, 0, 64); err == nil {
+		return int(intValue), nil
+	}
+	if floatValue, err := strconv.ParseFloat(number, 64); err == nil {
+		return floatValue, nil
+	}
+	return nil, fmt.Errorf("unsupported value %s", value)
+}
+
+// parseTOMLArray parses an array of values, allowing a trailing comma
+func parseTOMLArray(value string) ([]any, error) {
+	if !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("unterminated array %s", value)
+	}
+	body := value[1 : len(value)-1]
+
+	items := make([]any, 0)
+	start, depth := 0, 0
+	var quote byte
+	for i := 0; i <= len(body); i++ {
+		if i < len(body) {
+			ch := body[i]
+			switch {
+			case quote != 0:
+				if ch == '\\' && quote == '"' {
+					i++
+				} else if ch == quote {
+					quote = 0
+				}
+				continue
+			case ch == '"' || ch == '\'':
+				quote = ch
+				continue
+			case ch == '[':
+				depth++
+				continue
+			case ch == ']':
+				depth--
+				continue
+			case ch != ',' || depth > 0:
+				continue
+			}
+		}
+
+		element := strings.TrimSpace(body[start:i])
+		start = i + 1
+		if element == "" {
+			if i < len(body) {
+				return nil, fmt.Errorf("empty array element in %s", value)
+			}
+			continue
+		}
+		item, err := parseTOMLValue(element)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestConfigDecoders(t *testing.T) {
+	expected := map[string]any{
+		"verbose": true,
+		"deploy": map[string]any{
+			"region": "eu-west-1",
+			"tags":   []any{"a", "b"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		decoder ConfigDecoder
+		input   string
+	}{
+		{
+			name:    "json",
+			decoder: DecodeJSONConfig,
+			input:   `{"verbose": true, "deploy": {"region": "eu-west-1", "tags": ["a", "b"]}}`,
+		},
+		{
+			name:    "toml",
+			decoder: DecodeTOMLConfig,
+			input: `# global settings
+verbose = true
+
+[deploy]
+region = "eu-west-1" # trailing comment
+tags = [
+  "a",
+  'b',
+]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := tt.decoder([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(values, expected) {
+				t.Errorf("Expected %v, got %v", expected, values)
+			}
+		})
+	}
+
+	values, err := DecodeINIConfig([]byte("; comment\nverbose = true\n[deploy.prod]\nregion: \"us-east-1\"\ntag = a\ntag = b\n"))
+	if err != nil {
+		t.Fatalf("Unexpected INI error: %v", err)
+	}
+	prod := values["deploy"].(map[string]any)["prod"].(map[string]any)
+	if values["verbose"] != "true" || prod["region"] != "us-east-1" || !reflect.DeepEqual(prod["tag"], []any{"a", "b"}) {
+		t.Errorf("Unexpected INI values: %v", values)
+	}
+
+	if _, err := DecodeTOMLConfig([]byte("[[servers]]\n")); err == nil {
+		t.Error("Expected error for unsupported TOML arrays of tables")
+	}
+}
+
+func TestConfigFilePrecedence(t *testing.T) {
+	path := writeConfigFile(t, "app.toml", `
+verbose = true
+
+[deploy]
+region = "eu-west-1"
+replicas = 3
+strategy = "rolling"
+dry_run = true
+`)
+
+	newRoot := func() (*Command, *Command) {
+		root := NewCommand("app")
+		root.SetConfigOption("-c, --config <path>", "config file")
+		root.AddOption(NewBooleanOption("-v, --verbose", "verbose output"))
+
+		deploy := NewCommand("deploy")
+		deploy.AddOption(NewOption("--region <name>", "region").SetDefault("us-east-1"))
+		deploy.AddOption(CreateNumberOption("--replicas <n>", "replica count").SetEnv("TEST_APP_REPLICAS"))
+		deploy.AddOption(CreateChoiceOption("--strategy <name>", "strategy", []string{"rolling", "recreate"}))
+		deploy.AddOption(NewBooleanOption("--dry-run", "preview only"))
+		root.AddSubcommand(deploy)
+		return root, deploy
+	}
+
+	root, _ := newRoot()
+	t.Setenv("TEST_APP_REPLICAS", "5")
+	result, err := NewParser().ParseCommand(root, []string{"--config", path, "deploy", "--region", "ap-south-1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]struct {
+		value  any
+		source ValueSource
+	}{
+		"region":   {"ap-south-1", ValueSourceCLI},
+		"replicas": {5, ValueSourceEnv},
+		"strategy": {"rolling", ValueSourceConfig},
+		"dry-run":  {true, ValueSourceConfig},
+	}
+	for key, want := range expected {
+		if result.Options[key] != want.value || result.GetOptionValueSource(key) != want.source {
+			t.Errorf("Option %s: expected %v from %s, got %v from %s",
+				key, want.value, want.source, result.Options[key], result.GetOptionValueSource(key))
+		}
+	}
+	if result.Parent == nil || result.Parent.Options["verbose"] != true {
+		t.Errorf("Expected root verbose=true from config, got %v", result.Parent)
+	}
+
+	badPath := writeConfigFile(t, "bad.json", `{"deploy": {"strategy": "big-bang"}}`)
+	root, _ = newRoot()
+	_, err = NewParser().ParseCommand(root, []string{"-c", badPath, "deploy"})
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Path != badPath || configErr.Option != "--strategy" {
+		t.Errorf("Expected ConfigError for the strategy value in %s, got %v", badPath, err)
+	}
+
+	yamlPath := writeConfigFile(t, "app.yaml", "verbose: true\n")
+	root, _ = newRoot()
+	_, err = NewParser().ParseCommand(root, []string{"-c", yamlPath})
+	if !errors.As(err, &configErr) || configErr.Path != yamlPath {
+		t.Errorf("Expected ConfigError for YAML without a registered decoder, got %v", err)
+	}
+
+	root, _ = newRoot()
+	root.AddConfigDecoder("yaml", func(data []byte) (map[string]any, error) {
+		return map[string]any{"verbose": true}, nil
+	})
+	result, err = NewParser().ParseCommand(root, []string{"-c", yamlPath})
+	if err != nil {
+		t.Fatalf("Unexpected error with custom decoder: %v", err)
+	}
+	if result.Options["verbose"] != true {
+		t.Errorf("Expected verbose from custom decoder, got %v", result.Options["verbose"])
+	}
+}
+
+func TestConfigCountOption(t *testing.T) {
+	tests := []struct {
+		content string
+		want    any
+	}{
+		{`{"verbose": 3}`, 3},
+		{`{"verbose": true}`, 1},
+		{`{"verbose": "2"}`, 2},
+		{`{"verbose": -1}`, nil},
+		{`{"verbose": 1.5}`, nil},
+	}
+	for _, tt := range tests {
+		cmd := NewCommand("app")
+		cmd.SetConfigOption("-c, --config <path>", "config file")
+		cmd.AddOption(NewCountOption("-v, --verbose", "verbosity"))
+
+		path := writeConfigFile(t, "app.json", tt.content)
+		result, err := NewParser().ParseCommand(cmd, []string{"-c", path})
+		if tt.want == nil {
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) {
+				t.Errorf("%s: expected ConfigError, got %v", tt.content, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.content, err)
+		}
+		if result.Options["verbose"] != tt.want || result.GetOptionValueSource("verbose") != ValueSourceConfig {
+			t.Errorf("%s: expected verbose=%v from config, got %v (%s)",
+				tt.content, tt.want, result.Options["verbose"], result.GetOptionValueSource("verbose"))
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Runner is implemented by command structs whose Run method becomes the
+// command's action
+type Runner interface {
+	Run(ctx context.Context) error
+}
+
+// structField links an option or argument to the struct field it fills
+type structField struct {
+	index    []int
+	option   *Option
+	argument *Argument
+	argIndex int
+}
+
+// NewCommandFromStruct builds a command tree from a pointer to a tagged
+// struct. Fields become options, arguments or subcommands:
+//
+//	type Deploy struct {
+//		Env     string   `flags:"-e, --env <name>" desc:"target environment" choices:"dev,prod" required:"true"`
+//		Port    int      `flags:"-p, --port <n>" desc:"port" env:"PORT" default:"8080"`
+//		Debug   bool     `flags:"--debug" desc:"debug output" hidden:"true"`
+//		Service string   `arg:"0" desc:"service to deploy"`
+//		Hosts   []string `arg:"1" name:"hosts" variadic:"true" required:"false"`
+//		Status  Status   `cmd:"status" desc:"show deployment status"`
+//	}
+//
+// Arguments are ordered by their arg position and named by the name tag or the
+// lowercased field name. When the struct implements Runner, its Run method
+// becomes the action, called after the parsed values are stored in the fields.
+// The resulting tree is checked with Command.Validate.
+func NewCommandFromStruct(name string, spec any) (*Command, error) {
+	v := reflect.ValueOf(spec)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("command spec must be a non-nil pointer to a struct, got %T", spec)
+	}
+
+	command, err := buildStructCommand(name, v)
+	if err != nil {
+		return nil, err
+	}
+	if err := command.Validate(); err != nil {
+		return nil, err
+	}
+	return command, nil
+}
+
+// buildStructCommand builds the command for a struct pointer
+func buildStructCommand(name string, ptr reflect.Value) (*Command, error) {
+	command := NewCommand(name)
+	var fields []structField
+	var args []structField
+	var argFields []reflect.StructField
+
+	t := ptr.Elem().Type()
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		switch {
+		case hasTag(field, "cmd"):
+			sub, err := buildStructSubcommand(ptr.Elem().FieldByIndex(field.Index), field)
+			if err != nil {
+				return nil, err
+			}
+			command.AddSubcommand(sub)
+
+		case hasTag(field, "flags"):
+			option, err := structOption(field)
+			if err != nil {
+				return nil, fmt.Errorf("field %s of command '%s': %v", field.Name, name, err)
+			}
+			command.AddOption(option)
+			fields = append(fields, structField{index: field.Index, option: option})
+
+		case hasTag(field, "arg"):
+			position, err := strconv.Atoi(field.Tag.Get("arg"))
+			if err != nil || position < 0 {
+				return nil, fmt.Errorf("field %s of command '%s': invalid arg position %q", field.Name, name, field.Tag.Get("arg"))
+			}
+			args = append(args, structField{index: field.Index, argIndex: position})
+			argFields = append(argFields, field)
+		}
+	}
+
+	// Add arguments in position order
+	for position := 0; position < len(args); position++ {
+		found := -1
+		for i, arg := range args {
+			if arg.argIndex == position {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			return nil, fmt.Errorf("command '%s' has no argument at position %d", name, position)
+		}
+
+		argument, err := structArgument(argFields[found])
+		if err != nil {
+			return nil, fmt.Errorf("field %s of command '%s': %v", argFields[found].Name, name, err)
+		}
+		command.AddArgument(argument)
+		args[found].argument = argument
+		fields = append(fields, args[found])
+	}
+
+	if runner, ok := ptr.Interface().(Runner); ok {
+		command.SetContextAction(func(ctx context.Context, actionArgs []string, opts map[string]any) error {
+			parsed, ok := parsedCommandFrom(ctx)
+			if !ok {
+				// Called outside Dispatch, with only the flattened arguments
+				parsed = &ParsedCommand{Command: command, Options: opts, Arguments: positionalArguments(command, actionArgs)}
+			}
+			if err := fillStructFields(ptr.Elem(), fields, parsed); err != nil {
+				return err
+			}
+			return runner.Run(ctx)
+		})
+	}
+	return command, nil
+}
+
+// buildStructSubcommand builds a subcommand from a struct or struct pointer field
+func buildStructSubcommand(fieldValue reflect.Value, field reflect.StructField) (*Command, error) {
+	ptr := fieldValue.Addr()
+	switch {
+	case field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct:
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(field.Type.Elem()))
+		}
+		ptr = fieldValue
+	case field.Type.Kind() != reflect.Struct:
+		return nil, fmt.Errorf("subcommand field %s must be a struct or struct pointer, got %s", field.Name, field.Type)
+	}
+
+	sub, err := buildStructCommand(field.Tag.Get("cmd"), ptr)
+	if err != nil {
+		return nil, err
+	}
+	sub.Description = field.Tag.Get("desc")
+	sub.Hidden = field.Tag.Get("hidden") == "true"
+	if aliases := field.Tag.Get("aliases"); aliases != "" {
+		sub.SetAliases(splitTagList(aliases))
+	}
+	return sub, nil
+}
+
+// structOption creates the option described by a field's tags
+func structOption(field reflect.StructField) (*Option, error) {
+	flags, description := field.Tag.Get("flags"), field.Tag.Get("desc")
+
+	var option *Option
+	switch kind := derefType(field.Type).Kind(); {
+	case kind == reflect.Bool:
+		option = NewBooleanOption(flags, description)
+	case kind == reflect.Slice:
+		option = NewVariadicOption(flags, description)
+	case isNumberKind(kind) && derefType(field.Type) != durationType:
+		option = CreateNumberOption(flags, description)
+	default:
+		option = NewOption(flags, description)
+	}
+
+	if env := field.Tag.Get("env"); env != "" {
+		option.SetEnv(env)
+	}
+	if choices := field.Tag.Get("choices"); choices != "" {
+		option.SetChoices(splitTagList(choices))
+	}
+	option.SetRequired(field.Tag.Get("required") == "true")
+	option.SetHidden(field.Tag.Get("hidden") == "true")
+
+	if defaultValue, ok := field.Tag.Lookup("default"); ok {
+		converted, err := convertValue(tagValue(defaultValue, field.Type), field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default: %v", err)
+		}
+		option.SetDefault(converted.Interface())
+	}
+	return option, nil
+}
+
+// structArgument creates the argument described by a field's tags
+func structArgument(field reflect.StructField) (*Argument, error) {
+	name := field.Tag.Get("name")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+
+	argument := NewArgument(name, field.Tag.Get("desc"))
+	argument.SetVariadic(field.Tag.Get("variadic") == "true")
+	if field.Tag.Get("required") == "false" {
+		argument.SetRequired(false)
+		argument.ArgRequired = false
+		argument.ArgOptional = true
+	}
+	if choices := field.Tag.Get("choices"); choices != "" {
+		argument.SetChoices(splitTagList(choices))
+	}
+
+	if defaultValue, ok := field.Tag.Lookup("default"); ok {
+		converted, err := convertValue(tagValue(defaultValue, field.Type), field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("invalid default: %v", err)
+		}
+		argument.SetDefault(converted.Interface())
+	}
+	return argument, nil
+}
+
+// fillStructFields stores the parsed option and argument values in the
+// struct. The fields are reset first, so values left unset by this run do not
+// carry over from the previous one.
+func fillStructFields(v reflect.Value, fields []structField, parsed *ParsedCommand) error {
+	for _, field := range fields {
+		target := v.FieldByIndex(field.index)
+		target.Set(reflect.Zero(target.Type()))
+
+		var value any
+		var source string
+		if field.option != nil {
+			key := field.option.Long
+			if key == "" {
+				key = field.option.Short
+			}
+			stored, ok := parsed.Options[key]
+			if !ok {
+				continue
+			}
+			value, source = stored, fmt.Sprintf("option '%s'", field.option.displayName(stored == false))
+		} else {
+			stored, ok := parsed.lookupArgument(field.argument.Name)
+			if !ok {
+				continue
+			}
+			value, source = stored, fmt.Sprintf("argument '%s'", field.argument.Name)
+		}
+
+		converted, err := convertValue(value, target.Type())
+		if err != nil {
+			return fmt.Errorf("cannot store %s: %v", source, err)
+		}
+		target.Set(converted)
+	}
+	return nil
+}
+
+// positionalArguments assigns flattened action arguments to the command's
+// declared arguments in order, the variadic one taking the rest
+func positionalArguments(command *Command, args []string) []any {
+	values := make([]any, len(command.Arguments))
+	for i, argument := range command.Arguments {
+		if len(args) == 0 {
+			break
+		}
+		if argument.Variadic {
+			values[i] = args
+			break
+		}
+		values[i], args = args[0], args[1:]
+	}
+	return values
+}
+
+// derefType returns the element type of pointer types
+func derefType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
+
+// isNumberKind reports whether kind is an integer or float kind
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// splitTagList splits a comma-separated tag value
+func splitTagList(value string) []string {
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return parts
+}
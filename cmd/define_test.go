@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type deployStatusSpec struct {
+	Watch bool `flags:"-w, --watch" desc:"watch for changes"`
+	ran   bool
+}
+
+func (s *deployStatusSpec) Run(ctx context.Context) error {
+	s.ran = true
+	return nil
+}
+
+type deploySpec struct {
+	Env     string        `flags:"-e, --env <name>" desc:"target environment" choices:"dev,prod" required:"true"`
+	Port    int           `flags:"-p, --port <n>" desc:"port" env:"TEST_DEFINE_PORT" default:"8080"`
+	Timeout time.Duration `flags:"--timeout <duration>" desc:"deploy timeout" default:"1m"`
+	Tags    []string      `flags:"--tag <tags...>" desc:"tags"`
+	Debug   bool          `flags:"--debug" desc:"debug output" hidden:"true"`
+	Service string        `arg:"0" desc:"service to deploy"`
+	Hosts   []string      `arg:"1" name:"hosts" desc:"target hosts" variadic:"true" required:"false"`
+
+	Status  deployStatusSpec  `cmd:"status" desc:"show deployment status" aliases:"st"`
+	History *deployStatusSpec `cmd:"history" desc:"show history" hidden:"true"`
+
+	ran bool
+}
+
+func (d *deploySpec) Run(ctx context.Context) error {
+	d.ran = true
+	return nil
+}
+
+func TestNewCommandFromStruct(t *testing.T) {
+	spec := &deploySpec{}
+	deploy, err := NewCommandFromStruct("deploy", spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	env := deploy.FindOption("env")
+	if env == nil || !env.Required || !reflect.DeepEqual(env.Choices, []string{"dev", "prod"}) {
+		t.Errorf("Unexpected env option: %+v", env)
+	}
+	if port := deploy.FindOption("port"); port == nil || port.Env != "TEST_DEFINE_PORT" || port.Default != 8080 {
+		t.Errorf("Unexpected port option: %+v", port)
+	}
+	if debug := deploy.FindOption("debug"); debug == nil || !debug.Hidden || debug.Type != OptionTypeBoolean {
+		t.Errorf("Unexpected debug option: %+v", debug)
+	}
+	if len(deploy.Arguments) != 2 || deploy.Arguments[0].Name != "service" || !deploy.Arguments[1].Variadic || deploy.Arguments[1].Required {
+		t.Errorf("Unexpected arguments: %+v", deploy.Arguments)
+	}
+	if status := deploy.FindSubcommand("st"); status == nil || status.Name != "status" || status.Description != "show deployment status" {
+		t.Errorf("Unexpected status subcommand: %+v", status)
+	}
+	if history := deploy.FindSubcommand("history"); history == nil || !history.Hidden || spec.History == nil {
+		t.Errorf("Expected hidden history subcommand backed by an allocated struct")
+	}
+
+	t.Setenv("TEST_DEFINE_PORT", "9090")
+	if err := deploy.Execute([]string{"-e", "prod", "--tag", "a", "b", "--", "api", "h1", "h2"}); err != nil {
+		t.Fatalf("Unexpected execute error: %v", err)
+	}
+	if !spec.ran {
+		t.Fatal("Run should be called")
+	}
+	expected := deploySpec{
+		Env:     "prod",
+		Port:    9090,
+		Timeout: time.Minute,
+		Tags:    []string{"a", "b"},
+		Service: "api",
+		Hosts:   []string{"h1", "h2"},
+	}
+	got := *spec
+	got.Status, got.History, got.ran = deployStatusSpec{}, nil, false
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected fields:\n got: %+v\nwant: %+v", got, expected)
+	}
+
+	if err := deploy.Execute([]string{"-e", "dev", "st", "--watch"}); err != nil {
+		t.Fatalf("Unexpected subcommand error: %v", err)
+	}
+	if !spec.Status.ran || !spec.Status.Watch {
+		t.Errorf("Expected status subcommand to run with --watch, got %+v", spec.Status)
+	}
+}
+
+func TestNewCommandFromStructErrors(t *testing.T) {
+	if _, err := NewCommandFromStruct("bad", deploySpec{}); err == nil {
+		t.Error("Expected error for a non-pointer spec")
+	}
+
+	var gap struct {
+		First string `arg:"0"`
+		Third string `arg:"2"`
+	}
+	if _, err := NewCommandFromStruct("gap", &gap); err == nil || !strings.Contains(err.Error(), "position 1") {
+		t.Errorf("Expected missing position error, got %v", err)
+	}
+
+	var badDefault struct {
+		Port int `flags:"--port <n>" default:"eighty"`
+	}
+	if _, err := NewCommandFromStruct("bad", &badDefault); err == nil || !strings.Contains(err.Error(), "Port") {
+		t.Errorf("Expected invalid default error naming the field, got %v", err)
+	}
+
+	var duplicate struct {
+		A bool `flags:"-a, --all"`
+		B bool `flags:"-a, --any"`
+	}
+	if _, err := NewCommandFromStruct("dup", &duplicate); err == nil {
+		t.Error("Expected Validate to reject duplicate flags")
+	}
+}
+
+type releaseSpec struct {
+	Tags    []string `flags:"--tag <tags...>" desc:"tags"`
+	Service string   `arg:"0" desc:"service to release"`
+	Regions []string `arg:"1" desc:"comma-separated regions" required:"false"`
+	Hosts   []string `arg:"2" name:"hosts" variadic:"true" required:"false"`
+}
+
+func (r *releaseSpec) Run(ctx context.Context) error {
+	return nil
+}
+
+func TestNewCommandFromStructArguments(t *testing.T) {
+	spec := &releaseSpec{}
+	release, err := NewCommandFromStruct("release", spec)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	release.Arguments[1].SetParser(func(value string, previous any) (any, error) {
+		return strings.Split(value, ","), nil
+	})
+
+	// Test that typed parser values reach the field unchanged
+	if err := release.Execute([]string{"api", "eu,us", "h1", "--tag", "a"}); err != nil {
+		t.Fatalf("Unexpected execute error: %v", err)
+	}
+	expected := releaseSpec{Tags: []string{"a"}, Service: "api", Regions: []string{"eu", "us"}, Hosts: []string{"h1"}}
+	if !reflect.DeepEqual(*spec, expected) {
+		t.Errorf("Unexpected fields:\n got: %+v\nwant: %+v", *spec, expected)
+	}
+
+	// Test that values left unset by a later run are reset
+	if err := release.Execute([]string{"web"}); err != nil {
+		t.Fatalf("Unexpected execute error: %v", err)
+	}
+	if spec.Service != "web" || spec.Regions != nil || len(spec.Hosts) != 0 || len(spec.Tags) != 0 {
+		t.Errorf("Expected only the service to be set, got %+v", *spec)
+	}
+}
@@ -445,11 +445,12 @@ func TestParserEdgeCases(t *testing.T) {
 			setupCmd: func() *Command {
 				cmd := NewCommand("help-only")
 				// NewCommand automatically adds help option
+				cmd.ConfigureOutput(&OutputConfiguration{WriteOut: func(string) {}})
 				return cmd
 			},
 			args:        []string{"--help"},
-			expectError: false,
-			description: "Help option should be handled",
+			expectError: true,
+			description: "Help option should short-circuit with HelpDisplayedError",
 		},
 		{
 			name: "very long argument list",
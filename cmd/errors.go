@@ -1,6 +1,12 @@
 package cmd
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
 
 // ValidationError represents an error that occurs during command validation
 type ValidationError struct {
@@ -18,17 +24,31 @@ func (e *ValidationError) Error() string {
 	return fmt.Sprintf("validation error: %s", e.Message)
 }
 
-// ParseError represents an error that occurs during argument parsing
+// ParseError locates a parse failure on the command line. It wraps the typed
+// error describing the failure, so errors.As and AsCommanderError still find
+// the UnknownOptionError, MissingArgumentError or other error underneath.
 type ParseError struct {
 	Command  string
 	Argument string
 	Option   string
 	Value    string
 	Message  string
+	Code     string
+
+	// Position is the index in Args of the offending argument, len(Args) when
+	// something is missing at the end of the command line, or -1 when the
+	// failure does not come from the command line, e.g. an invalid env value
 	Position int
+	Token    string
+	Args     []string
+
+	Err error
 }
 
 func (e *ParseError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
 	if e.Option != "" {
 		return fmt.Sprintf("parse error for option '%s' in command '%s': %s", e.Option, e.Command, e.Message)
 	} else if e.Argument != "" {
@@ -37,6 +57,73 @@ func (e *ParseError) Error() string {
 	return fmt.Sprintf("parse error in command '%s': %s", e.Command, e.Message)
 }
 
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Render returns the error message followed by the command line, starting with
+// the program name, and a caret under the offending argument:
+//
+//	unknown option '--colr'
+//	  app build --colr auto
+//	            ^^^^^^
+//
+// Only the message is returned when the error has no position.
+func (e *ParseError) Render() string {
+	message := e.Error()
+	if e.Position < 0 || e.Position > len(e.Args) {
+		return message
+	}
+
+	var words []string
+	if program, _, _ := strings.Cut(e.Command, " "); program != "" {
+		words = append(words, program)
+	}
+	column := 0
+	for i, arg := range e.Args {
+		if i == e.Position {
+			column = caretColumn(words)
+		}
+		words = append(words, quoteArg(arg))
+	}
+
+	width := 1
+	if e.Position == len(e.Args) {
+		column = caretColumn(words)
+	} else {
+		width = max(utf8.RuneCountInString(quoteArg(e.Args[e.Position])), 1)
+	}
+	return fmt.Sprintf("%s\n  %s\n  %s%s", message, strings.Join(words, " "),
+		strings.Repeat(" ", column), strings.Repeat("^", width))
+}
+
+// RenderError renders err with Render when it is or wraps a ParseError, and
+// returns its message otherwise
+func RenderError(err error) string {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.Render()
+	}
+	return err.Error()
+}
+
+// caretColumn returns the column following the words joined by spaces
+func caretColumn(words []string) int {
+	if len(words) == 0 {
+		return 0
+	}
+	return utf8.RuneCountInString(strings.Join(words, " ")) + 1
+}
+
+// quoteArg quotes a command-line argument that is empty or would otherwise
+// not read back as one word
+func quoteArg(arg string) string {
+	if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+		return strconv.Quote(arg)
+	}
+	return arg
+}
+
 // CommanderError represents a general Commander error (compatible with Commander.js)
 type CommanderError struct {
 	Code     string
@@ -49,6 +136,20 @@ func (e *CommanderError) Error() string {
 	return e.Message
 }
 
+func (e *CommanderError) commanderError() *CommanderError {
+	return e
+}
+
+// AsCommanderError extracts the CommanderError carried by err, including the
+// typed errors below that embed one
+func AsCommanderError(err error) (*CommanderError, bool) {
+	var target interface{ commanderError() *CommanderError }
+	if errors.As(err, &target) {
+		return target.commanderError(), true
+	}
+	return nil, false
+}
+
 // InvalidArgumentError represents an invalid argument error (compatible with Commander.js)
 type InvalidArgumentError struct {
 	*CommanderError
@@ -121,10 +222,46 @@ func NewMissingOptionError(option string) *MissingOptionError {
 	}
 }
 
+// OptionMissingArgumentError represents an option given without its required value
+type OptionMissingArgumentError struct {
+	*CommanderError
+	Option string
+}
+
+func NewOptionMissingArgumentError(option string) *OptionMissingArgumentError {
+	return &OptionMissingArgumentError{
+		CommanderError: &CommanderError{
+			Code:     "commander.optionMissingArgument",
+			Message:  fmt.Sprintf("option '%s' argument missing", option),
+			ExitCode: 1,
+		},
+		Option: option,
+	}
+}
+
+// OptionRepeatedError represents an option given more than once when its
+// accumulation mode rejects repeats
+type OptionRepeatedError struct {
+	*CommanderError
+	Option string
+}
+
+func NewOptionRepeatedError(option string) *OptionRepeatedError {
+	return &OptionRepeatedError{
+		CommanderError: &CommanderError{
+			Code:     "commander.optionRepeated",
+			Message:  fmt.Sprintf("option '%s' cannot be specified more than once", option),
+			ExitCode: 1,
+		},
+		Option: option,
+	}
+}
+
 // UnknownOptionError represents an unknown option error
 type UnknownOptionError struct {
 	*CommanderError
-	Option string
+	Option     string
+	Suggestion string
 }
 
 func NewUnknownOptionError(option string) *UnknownOptionError {
@@ -138,6 +275,66 @@ func NewUnknownOptionError(option string) *UnknownOptionError {
 	}
 }
 
+// WithSuggestion attaches a "did you mean" hint, which is shown after the message
+func (e *UnknownOptionError) WithSuggestion(suggestion string) *UnknownOptionError {
+	e.Suggestion = suggestion
+	if suggestion != "" {
+		e.Message += "\n" + suggestion
+	}
+	return e
+}
+
+// UnknownCommandError represents an unknown command error
+type UnknownCommandError struct {
+	*CommanderError
+	Name       string
+	Suggestion string
+}
+
+func NewUnknownCommandError(name, suggestion string) *UnknownCommandError {
+	message := fmt.Sprintf("unknown command '%s'", name)
+	if suggestion != "" {
+		message += "\n" + suggestion
+	}
+	return &UnknownCommandError{
+		CommanderError: &CommanderError{
+			Code:     "commander.unknownCommand",
+			Message:  message,
+			ExitCode: 1,
+		},
+		Name:       name,
+		Suggestion: suggestion,
+	}
+}
+
+// AmbiguousError represents an abbreviated option or command name matching
+// more than one candidate
+type AmbiguousError struct {
+	*CommanderError
+	Input      string
+	Candidates []string
+}
+
+func NewAmbiguousOptionError(input string, candidates []string) *AmbiguousError {
+	return newAmbiguousError("commander.ambiguousOption", "option", input, candidates)
+}
+
+func NewAmbiguousCommandError(input string, candidates []string) *AmbiguousError {
+	return newAmbiguousError("commander.ambiguousCommand", "command", input, candidates)
+}
+
+func newAmbiguousError(code, kind, input string, candidates []string) *AmbiguousError {
+	return &AmbiguousError{
+		CommanderError: &CommanderError{
+			Code:     code,
+			Message:  fmt.Sprintf("ambiguous %s '%s' could match %s", kind, input, strings.Join(candidates, ", ")),
+			ExitCode: 1,
+		},
+		Input:      input,
+		Candidates: candidates,
+	}
+}
+
 // ConflictingOptionError represents a conflicting option error
 type ConflictingOptionError struct {
 	*CommanderError
@@ -157,6 +354,53 @@ func NewConflictingOptionError(option1, option2 string) *ConflictingOptionError
 	}
 }
 
+// OptionGroupError represents a violated option group constraint
+type OptionGroupError struct {
+	*CommanderError
+	Group   string
+	Options []string
+}
+
+func NewOptionGroupError(code, message, group string, options []string) *OptionGroupError {
+	return &OptionGroupError{
+		CommanderError: &CommanderError{
+			Code:     code,
+			Message:  message,
+			ExitCode: 1,
+		},
+		Group:   group,
+		Options: options,
+	}
+}
+
+// AggregateError holds the errors collected in one parse with
+// Parser.CollectErrors. Like the error from errors.Join, its message lists
+// the messages of its errors on separate lines and it unwraps to them, so
+// errors.Is and errors.As search each one.
+type AggregateError struct {
+	*CommanderError
+	Errors []error
+}
+
+func NewAggregateError(errs []error) *AggregateError {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return &AggregateError{
+		CommanderError: &CommanderError{
+			Code:     "commander.aggregateError",
+			Message:  strings.Join(messages, "\n"),
+			ExitCode: 1,
+		},
+		Errors: errs,
+	}
+}
+
+func (e *AggregateError) Unwrap() []error {
+	return e.Errors
+}
+
 // ExcessArgumentsError represents an error when too many arguments are provided
 type ExcessArgumentsError struct {
 	*CommanderError
@@ -176,6 +420,32 @@ func NewExcessArgumentsError(expected, received int) *ExcessArgumentsError {
 	}
 }
 
+// ConfigError represents a config file that could not be loaded or that
+// holds an invalid value for an option
+type ConfigError struct {
+	*CommanderError
+	Path   string
+	Option string
+	Err    error
+}
+
+func NewConfigError(message, path, option string, err error) *ConfigError {
+	return &ConfigError{
+		CommanderError: &CommanderError{
+			Code:     "commander.configError",
+			Message:  message,
+			ExitCode: 1,
+		},
+		Path:   path,
+		Option: option,
+		Err:    err,
+	}
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
 // HelpDisplayedError represents when help is displayed (not really an error)
 type HelpDisplayedError struct {
 	*CommanderError
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// executableWaitDelay is how long a cancelled executable subcommand may run
+// before it is killed
+const executableWaitDelay = 5 * time.Second
+
+// executableName returns the program name for an executable subcommand:
+// ExecutableFile when set, otherwise "<parent>-<subcommand>" as in git
+func (c *Command) executableName() string {
+	if c.ExecutableFile != "" {
+		return c.ExecutableFile
+	}
+	if c.Parent == nil {
+		return c.Name
+	}
+	return c.Parent.Name + "-" + c.Name
+}
+
+// executableSearchDirs returns the directories searched before PATH: the
+// ExecutableDir (relative to the running binary unless absolute), then the
+// directory of the running binary
+func (c *Command) executableSearchDirs() []string {
+	var binDir string
+	if self, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(self); err == nil {
+			self = resolved
+		}
+		binDir = filepath.Dir(self)
+	}
+
+	var dirs []string
+	if dir := c.ExecutableDir; dir != "" {
+		if !filepath.IsAbs(dir) && binDir != "" {
+			dir = filepath.Join(binDir, dir)
+		}
+		dirs = append(dirs, dir)
+	}
+	if binDir != "" {
+		dirs = append(dirs, binDir)
+	}
+	return dirs
+}
+
+// FindExecutable resolves the program run for an executable subcommand. It
+// searches ExecutableDir, the directory of the running binary, then PATH,
+// and returns the locations searched when nothing is found.
+func (c *Command) FindExecutable() (string, []string, error) {
+	name := c.executableName()
+	if filepath.IsAbs(name) {
+		if isExecutableFile(name) {
+			return name, nil, nil
+		}
+		return "", []string{name}, fmt.Errorf("executable '%s' does not exist", name)
+	}
+
+	var searched []string
+	for _, dir := range c.executableSearchDirs() {
+		for _, candidate := range executableCandidates(filepath.Join(dir, name)) {
+			searched = append(searched, candidate)
+			if isExecutableFile(candidate) {
+				return candidate, searched, nil
+			}
+		}
+	}
+
+	if !strings.ContainsRune(name, filepath.Separator) {
+		searched = append(searched, "$PATH")
+		if path, err := exec.LookPath(name); err == nil {
+			return path, searched, nil
+		}
+	}
+
+	return "", searched, fmt.Errorf("executable '%s' not found", name)
+}
+
+// executableCandidates returns path plus its Windows executable variants
+func executableCandidates(path string) []string {
+	if runtime.GOOS != "windows" || filepath.Ext(path) != "" {
+		return []string{path}
+	}
+	return []string{path + ".exe", path + ".cmd", path + ".bat", path}
+}
+
+// isExecutableFile reports whether path is a regular file the user can run
+func isExecutableFile(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode().Perm()&0o111 != 0
+}
+
+// runExecutable spawns the executable subcommand with the remaining
+// arguments, inheriting stdio and environment and forwarding signals. A
+// non-zero exit status is returned as a CommanderError with the child's code.
+func (c *Command) runExecutable(ctx context.Context, args []string) error {
+	path, searched, err := c.FindExecutable()
+	if err != nil {
+		message := fmt.Sprintf("%v for command '%s'", err, c.GetFullName())
+		if len(searched) > 0 {
+			message += "\nsearched:\n - " + strings.Join(searched, "\n - ")
+		}
+		message += "\nuse SetExecutable to supply a custom name or path, or SetExecutableDir to change where it is searched"
+		return &CommanderError{
+			Code:     "commander.executableNotFound",
+			Message:  message,
+			ExitCode: 1,
+			Command:  c.GetFullName(),
+		}
+	}
+
+	child := exec.CommandContext(ctx, path, args...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	// Signals reach the child through the relay below, so a context cancelled
+	// by one of them leaves the child to handle it. Any other cancellation
+	// interrupts the child, which is killed if it is still running after
+	// executableWaitDelay.
+	var forwarded atomic.Bool
+	child.Cancel = func() error {
+		if forwarded.Load() {
+			return nil
+		}
+		if err := child.Process.Signal(os.Interrupt); err != nil {
+			return child.Process.Kill()
+		}
+		return nil
+	}
+	child.WaitDelay = executableWaitDelay
+
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("failed to start executable '%s' for command '%s': %w", path, c.GetFullName(), err)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				forwarded.Store(true)
+				_ = child.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err = child.Wait()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code := exitStatus(exitErr)
+		return &CommanderError{
+			Code:     "commander.executeSubCommandAsync",
+			Message:  fmt.Sprintf("command '%s' exited with code %d", c.GetFullName(), code),
+			ExitCode: code,
+			Command:  c.GetFullName(),
+		}
+	}
+	return err
+}
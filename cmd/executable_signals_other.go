@@ -0,0 +1,21 @@
+//go:build !unix
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// forwardedSignals are relayed to executable subcommands while they run
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// exitStatus returns the exit code to report for an executable subcommand,
+// or 1 when the platform reports none
+func exitStatus(err *exec.ExitError) int {
+	if code := err.ExitCode(); code >= 0 {
+		return code
+	}
+	return 1
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// forwardedSignals are relayed to executable subcommands while they run
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGUSR2}
+
+// exitStatus returns the exit code to report for an executable subcommand,
+// 128+n for a child killed by signal n as in POSIX shells
+func exitStatus(err *exec.ExitError) int {
+	if status, ok := err.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	if code := err.ExitCode(); code >= 0 {
+		return code
+	}
+	return 1
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func writeExecutable(t *testing.T, dir, name, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script executables are not supported on Windows")
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatalf("Failed to write executable: %v", err)
+	}
+}
+
+func TestExecutableSubcommand(t *testing.T) {
+	dir := t.TempDir()
+	outFile := filepath.Join(dir, "args.txt")
+	writeExecutable(t, dir, "app-greet", `printf '%s\n' "$@" > "$OUT_FILE"; exit 3`+"\n")
+	t.Setenv("OUT_FILE", outFile)
+
+	root := NewCommand("app")
+	root.AddOption(NewBooleanOption("-v, --verbose", "verbose output"))
+	greet := NewCommand("greet")
+	greet.SetExecutableDir(dir)
+	greet.ExecutableHandler = true
+	root.AddSubcommand(greet)
+
+	var hookCalled bool
+	root.AddHook(HookEventPreSubcommand, func(thisCmd, actionCmd *Command) error {
+		hookCalled = true
+		return nil
+	})
+
+	err := root.Execute([]string{"-v", "greet", "--name=world", "-abc", "--", "--help"})
+	cmdErr, ok := AsCommanderError(err)
+	if !ok || cmdErr.Code != "commander.executeSubCommandAsync" || cmdErr.ExitCode != 3 {
+		t.Fatalf("Expected exit code 3 from executable, got %v", err)
+	}
+	if !hookCalled {
+		t.Error("preSubcommand hook should run before the executable")
+	}
+
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("Executable did not run: %v", err)
+	}
+	if got := string(data); got != "--name=world\n-abc\n--\n--help\n" {
+		t.Errorf("Expected raw arguments to be forwarded, got %q", got)
+	}
+}
+
+func TestExecutableSubcommandSignalled(t *testing.T) {
+	dir := t.TempDir()
+	outFile := filepath.Join(dir, "trap.txt")
+	writeExecutable(t, dir, "app-crash", "kill -TERM $$\n")
+	writeExecutable(t, dir, "app-wait", `trap 'echo interrupted > "$OUT_FILE"; kill $!; exit 7' INT; sleep 5 & wait`+"\n")
+	t.Setenv("OUT_FILE", outFile)
+
+	root := NewCommand("app")
+	root.AddSubcommand(NewCommand("crash").SetExecutableDir(dir).SetExecutable("app-crash"))
+	root.AddSubcommand(NewCommand("wait").SetExecutableDir(dir).SetExecutable("app-wait").SetTimeout(100 * time.Millisecond))
+
+	err := root.Execute([]string{"crash"})
+	if cmdErr, ok := AsCommanderError(err); !ok || cmdErr.ExitCode != 128+int(syscall.SIGTERM) {
+		t.Errorf("Expected exit code %d for a child killed by SIGTERM, got %v", 128+int(syscall.SIGTERM), err)
+	}
+
+	// Test that a timeout interrupts the child instead of killing it
+	if err := root.Execute([]string{"wait"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if data, _ := os.ReadFile(outFile); string(data) != "interrupted\n" {
+		t.Errorf("Expected the child to handle the interrupt, got %q", data)
+	}
+}
+
+func TestExecutableSubcommandSearchesPath(t *testing.T) {
+	dir := t.TempDir()
+	writeExecutable(t, dir, "app-status", "exit 0\n")
+	t.Setenv("PATH", dir)
+
+	root := NewCommand("app")
+	root.AddSubcommand(NewCommand("status").SetExecutable("app-status"))
+
+	if err := root.Execute([]string{"status"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestExecutableSubcommandMissing(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", "")
+
+	root := NewCommand("app")
+	sync := NewCommand("sync")
+	sync.SetExecutableDir(dir)
+	sync.ExecutableHandler = true
+	root.AddSubcommand(sync)
+
+	err := root.Execute([]string{"sync"})
+	cmdErr, ok := AsCommanderError(err)
+	if !ok || cmdErr.Code != "commander.executableNotFound" {
+		t.Fatalf("Expected executableNotFound error, got %v", err)
+	}
+	for _, want := range []string{"app-sync", filepath.Join(dir, "app-sync"), "$PATH"} {
+		if !strings.Contains(cmdErr.Message, want) {
+			t.Errorf("Expected message to mention %q, got:\n%s", want, cmdErr.Message)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+)
+
+// Execute parses the given arguments and dispatches to the resolved command.
+// The arguments should not include the program name, e.g. Execute(os.Args[1:]).
+func (c *Command) Execute(args []string) error {
+	return c.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext is like Execute but stops before running hooks or the action
+// once the context is done. The context is cancelled on any signal registered
+// with HandleSignals and bounded by the Timeout of each command on the path
+// to the resolved command.
+func (c *Command) ExecuteContext(ctx context.Context, args []string) error {
+	if len(c.CancelSignals) > 0 {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, c.CancelSignals...)
+		defer stop()
+	}
+
+	// Completion requests carry raw command line words, not options to parse
+	if c.isCompletionCommand(args) {
+		c.writeCompletions(args[1:])
+		return nil
+	}
+
+	parsed, err := c.NewParser().ParseCommand(c, args)
+	if err != nil {
+		return err
+	}
+
+	return c.Dispatch(ctx, parsed)
+}
+
+// NewParser creates a parser configured from the command's parsing settings
+func (c *Command) NewParser() *Parser {
+	parser := NewParser()
+	parser.AllowUnknownOptions = c.AllowUnknownOption
+	parser.EnablePositionalOptions = c.EnablePositionalOptions
+	parser.PassThroughOptions = c.PassThroughOptions
+	parser.CombineFlagAndOptionalValue = c.CombineFlagAndOptionalValue
+	parser.AllowAbbreviations = c.AllowAbbreviations
+	parser.StopAtFirstPositional = c.StopAtFirstPositional
+	parser.StopAtFirstUnknown = c.StopAtFirstUnknown
+	parser.CollectErrors = c.CollectErrors
+	return parser
+}
+
+// Dispatch runs the lifecycle for a parse result produced from this command.
+// Hooks fire in Commander.js order: preSubcommand on each parent as the chain
+// is walked, preAction from this command down to the leaf, then the leaf's
+// action, then postAction from the leaf back up. An executable leaf is
+// spawned after the preSubcommand hooks.
+func (c *Command) Dispatch(ctx context.Context, parsed *ParsedCommand) error {
+	leaf := parsed.Command
+	if leaf == nil {
+		leaf = c
+	}
+
+	chain := c.commandChain(leaf)
+	if chain == nil {
+		return fmt.Errorf("command '%s' is not a descendant of '%s'", leaf.GetFullName(), c.GetFullName())
+	}
+
+	// Every timeout along the chain applies, so the tightest deadline wins
+	for _, command := range chain {
+		var cancel context.CancelFunc
+		ctx, cancel = command.withTimeout(ctx)
+		defer cancel()
+	}
+
+	ctx = context.WithValue(ctx, parsedCommandKey{}, parsed)
+
+	for i := 0; i < len(chain)-1; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := chain[i].ExecuteHooksContext(ctx, HookEventPreSubcommand, chain[i+1]); err != nil {
+			return err
+		}
+	}
+
+	// Executable subcommands run as a separate program without action hooks
+	if leaf.IsExecutableSubcommand() {
+		return leaf.runExecutable(ctx, parsed.Unknown)
+	}
+
+	if !leaf.hasActionHandler() {
+		return leaf.handleMissingAction(parsed)
+	}
+
+	for _, command := range chain {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := command.ExecuteHooksContext(ctx, HookEventPreAction, leaf); err != nil {
+			return err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	actionErr := leaf.runAction(ctx, actionArguments(parsed), parsed.Options)
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if hookErr := chain[i].ExecuteHooksContext(ctx, HookEventPostAction, leaf); hookErr != nil {
+			if actionErr != nil {
+				return fmt.Errorf("action failed: %v; post-action hook failed: %v", actionErr, hookErr)
+			}
+			return hookErr
+		}
+	}
+
+	return actionErr
+}
+
+// withTimeout bounds the context by the command's Timeout, if one is set
+func (c *Command) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.Timeout > 0 {
+		return context.WithTimeout(ctx, c.Timeout)
+	}
+	return ctx, func() {}
+}
+
+// parsedCommandKey is the context key under which Dispatch stores the parse result
+type parsedCommandKey struct{}
+
+// parsedCommandFrom returns the parse result being dispatched, if any
+func parsedCommandFrom(ctx context.Context) (*ParsedCommand, bool) {
+	parsed, ok := ctx.Value(parsedCommandKey{}).(*ParsedCommand)
+	return parsed, ok
+}
+
+// commandChain returns the commands from c down to leaf, or nil if leaf is not below c
+func (c *Command) commandChain(leaf *Command) []*Command {
+	var chain []*Command
+	for current := leaf; current != nil; current = current.Parent {
+		chain = append([]*Command{current}, chain...)
+		if current == c {
+			return chain
+		}
+	}
+	return nil
+}
+
+// hasActionHandler returns true if the command has any sync or async action
+func (c *Command) hasActionHandler() bool {
+	return c.Action != nil || c.AsyncAction != nil ||
+		c.ContextAction != nil || c.ContextAsyncAction != nil
+}
+
+// runAction invokes the command's action, preferring async over sync and
+// context-aware over plain handlers. Async results are abandoned with the
+// context's error once it is done; sync handlers are expected to observe ctx.
+func (c *Command) runAction(ctx context.Context, args []string, opts map[string]any) error {
+	switch {
+	case c.ContextAsyncAction != nil:
+		return awaitAction(ctx, c.ContextAsyncAction(ctx, args, opts))
+	case c.AsyncAction != nil:
+		return awaitAction(ctx, c.AsyncAction(args, opts))
+	case c.ContextAction != nil:
+		return c.ContextAction(ctx, args, opts)
+	case c.Action != nil:
+		return c.Action(args, opts)
+	}
+	return nil
+}
+
+// awaitAction waits for an async action result or the end of the context
+func awaitAction(ctx context.Context, errChan <-chan error) error {
+	select {
+	case err := <-errChan:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// handleMissingAction mirrors Commander.js when the resolved command has no action:
+// a command with subcommands reports the unknown operand or displays help
+func (c *Command) handleMissingAction(parsed *ParsedCommand) error {
+	if !c.HasSubcommands() {
+		return nil
+	}
+
+	if operands := unknownOperands(append(parsed.Unknown, parsed.PassThroughArgs...)); len(operands) > 0 {
+		return c.unknownCommandError(operands[0])
+	}
+
+	c.WriteErr(c.generateErrHelp())
+	return &CommanderError{
+		Code:     "commander.help",
+		Message:  "(outputHelp)",
+		ExitCode: 1,
+		Command:  c.GetFullName(),
+	}
+}
+
+// actionArguments flattens parsed argument values into the string form expected
+// by ActionHandler. Declared arguments come first (variadic values expanded),
+// followed by any excess or unknown arguments collected during parsing and
+// the pass-through arguments left after option processing stopped.
+func actionArguments(parsed *ParsedCommand) []string {
+	args := make([]string, 0, len(parsed.Arguments)+len(parsed.Unknown)+len(parsed.PassThroughArgs))
+	for _, value := range parsed.Arguments {
+		switch v := value.(type) {
+		case nil:
+			continue
+		case []any:
+			for _, item := range v {
+				args = append(args, fmt.Sprintf("%v", item))
+			}
+		case []string:
+			args = append(args, v...)
+		default:
+			args = append(args, fmt.Sprintf("%v", v))
+		}
+	}
+	args = append(args, parsed.Unknown...)
+	return append(args, parsed.PassThroughArgs...)
+}
+
+// unknownCommandError reports an unknown subcommand name, with a suggestion
+// when ShowSuggestionAfterError is enabled
+func (c *Command) unknownCommandError(name string) *UnknownCommandError {
+	var suggestion string
+	if c.ShowSuggestionAfterError {
+		suggestion = c.GenerateSuggestion(name)
+	}
+	err := NewUnknownCommandError(name, suggestion)
+	err.Command = c.GetFullName()
+	return err
+}
+
+// unknownOperands returns the unknown values that do not look like options
+func unknownOperands(unknown []string) []string {
+	var operands []string
+	for _, value := range unknown {
+		if len(value) > 1 && value[0] == '-' {
+			continue
+		}
+		operands = append(operands, value)
+	}
+	return operands
+}
@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecuteDispatchesToSubcommand(t *testing.T) {
+	root := NewCommand("app")
+	root.AddOption(NewBooleanOption("-v, --verbose", "verbose output"))
+
+	deploy := NewCommand("deploy")
+	deploy.AddOption(NewOption("-e, --env <name>", "target environment"))
+	deploy.AddArgument(NewArgument("<service>", "service to deploy"))
+	deploy.AddArgument(NewArgument("[hosts...]", "target hosts"))
+	root.AddSubcommand(deploy)
+
+	var gotArgs []string
+	var gotOpts map[string]any
+	deploy.SetAction(func(args []string, opts map[string]any) error {
+		gotArgs = args
+		gotOpts = opts
+		return nil
+	})
+
+	if err := root.Execute([]string{"deploy", "--env", "prod", "api", "h1", "h2"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(gotArgs, []string{"api", "h1", "h2"}) {
+		t.Errorf("Expected args [api h1 h2], got %v", gotArgs)
+	}
+	if gotOpts["env"] != "prod" {
+		t.Errorf("Expected env option 'prod', got %v", gotOpts["env"])
+	}
+}
+
+func TestExecuteHookOrder(t *testing.T) {
+	var calls []string
+	record := func(name string) HookHandler {
+		return func(thisCmd, actionCmd *Command) error {
+			calls = append(calls, fmt.Sprintf("%s:%s->%s", name, thisCmd.Name, actionCmd.Name))
+			return nil
+		}
+	}
+
+	root := NewCommand("root")
+	mid := NewCommand("mid")
+	leaf := NewCommand("leaf")
+	root.AddSubcommand(mid)
+	mid.AddSubcommand(leaf)
+
+	root.AddHook(HookEventPreSubcommand, record("preSubcommand"))
+	mid.AddHook(HookEventPreSubcommand, record("preSubcommand"))
+	root.AddHook(HookEventPreAction, record("preAction"))
+	leaf.AddHook(HookEventPreAction, record("preAction"))
+	root.AddHook(HookEventPostAction, record("postAction"))
+	leaf.AddHook(HookEventPostAction, record("postAction"))
+	leaf.SetAction(func(args []string, opts map[string]any) error {
+		calls = append(calls, "action")
+		return nil
+	})
+
+	if err := root.Execute([]string{"mid", "leaf"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"preSubcommand:root->mid",
+		"preSubcommand:mid->leaf",
+		"preAction:root->leaf",
+		"preAction:leaf->leaf",
+		"action",
+		"postAction:leaf->leaf",
+		"postAction:root->leaf",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Unexpected hook order:\n got: %v\nwant: %v", calls, expected)
+	}
+}
+
+func TestExecuteAsyncAction(t *testing.T) {
+	root := NewCommand("root")
+	root.SetAsyncAction(func(args []string, opts map[string]any) <-chan error {
+		ch := make(chan error, 1)
+		ch <- fmt.Errorf("async failure")
+		return ch
+	})
+
+	err := root.Execute(nil)
+	if err == nil || err.Error() != "async failure" {
+		t.Errorf("Expected async failure error, got %v", err)
+	}
+}
+
+func TestExecuteUnknownCommand(t *testing.T) {
+	root := NewCommand("root")
+	root.ConfigureOutput(&OutputConfiguration{WriteErr: func(string) {}})
+	root.AddSubcommand(NewCommand("start"))
+
+	err := root.Execute([]string{"stop"})
+	cmdErr, ok := AsCommanderError(err)
+	if !ok || cmdErr.Code != "commander.unknownCommand" {
+		t.Fatalf("Expected unknownCommand error, got %v", err)
+	}
+	if !strings.Contains(cmdErr.Message, "stop") {
+		t.Errorf("Expected message to name the unknown command, got %q", cmdErr.Message)
+	}
+}
+
+func TestExecuteContextCancelled(t *testing.T) {
+	root := NewCommand("root")
+	called := false
+	root.SetAction(func(args []string, opts map[string]any) error {
+		called = true
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := root.ExecuteContext(ctx, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if called {
+		t.Error("Action should not run after the context is cancelled")
+	}
+}
+
+func TestExecuteHelpOptionAtDepth(t *testing.T) {
+	var out strings.Builder
+	root := NewCommand("app")
+	root.ConfigureOutput(&OutputConfiguration{WriteOut: func(s string) { out.WriteString(s) }})
+	deploy := NewCommand("deploy")
+	deploy.Description = "deploy a service"
+	deploy.AddOption(CreateRequiredOption("-e, --env <name>", "target environment"))
+	deploy.CopyInheritedSettings(root)
+	root.AddSubcommand(deploy)
+
+	err := root.Execute([]string{"deploy", "--help"})
+	var helpErr *HelpDisplayedError
+	if !errors.As(err, &helpErr) {
+		t.Fatalf("Expected HelpDisplayedError, got %v", err)
+	}
+	if helpErr.ExitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d", helpErr.ExitCode)
+	}
+	if !strings.Contains(out.String(), "Usage: app deploy") {
+		t.Errorf("Expected deploy help, got %q", out.String())
+	}
+}
+
+func TestExecuteVersionOption(t *testing.T) {
+	var out strings.Builder
+	root := NewCommand("app")
+	root.ConfigureOutput(&OutputConfiguration{WriteOut: func(s string) { out.WriteString(s) }})
+	root.SetVersion("1.2.3")
+
+	for _, flag := range []string{"-V", "--version"} {
+		out.Reset()
+		err := root.Execute([]string{flag})
+		var versionErr *VersionDisplayedError
+		if !errors.As(err, &versionErr) {
+			t.Fatalf("Expected VersionDisplayedError for %s, got %v", flag, err)
+		}
+		if out.String() != "1.2.3\n" {
+			t.Errorf("Expected version output, got %q", out.String())
+		}
+	}
+
+	// Test that parsing leaves a version set on the field unregistered
+	sub := NewCommand("sub")
+	sub.Version = "0.1.0"
+	root.AddSubcommand(sub)
+	optionCount := len(sub.Options)
+	if _, err := NewParser().ParseCommand(root, []string{"sub"}); err != nil {
+		t.Fatalf("Unexpected parse error: %v", err)
+	}
+	if len(sub.Options) != optionCount || sub.VersionOption != nil {
+		t.Error("Parsing should not register a version option")
+	}
+}
+
+func TestExecuteHelpCommand(t *testing.T) {
+	var out strings.Builder
+	root := NewCommand("app")
+	root.ConfigureOutput(&OutputConfiguration{WriteOut: func(s string) { out.WriteString(s) }})
+	remote := NewCommand("remote")
+	remote.CopyInheritedSettings(root)
+	add := NewCommand("add")
+	add.CopyInheritedSettings(root)
+	remote.AddSubcommand(add)
+	root.AddSubcommand(remote)
+
+	err := root.Execute([]string{"help", "remote", "add"})
+	if _, ok := AsCommanderError(err); !ok || !errors.As(err, new(*HelpDisplayedError)) {
+		t.Fatalf("Expected HelpDisplayedError, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Usage: app remote add") {
+		t.Errorf("Expected help for 'app remote add', got %q", out.String())
+	}
+
+	root.SetHelpCommand(nil)
+	if root.GetHelpCommand() != nil {
+		t.Error("Help command should be disabled")
+	}
+}
+
+func TestExecuteContextActionTimeout(t *testing.T) {
+	root := NewCommand("root")
+	root.SetTimeout(20 * time.Millisecond)
+
+	var hookSawDeadline bool
+	root.AddContextHook(HookEventPreAction, func(ctx context.Context, thisCmd, actionCmd *Command) error {
+		_, hookSawDeadline = ctx.Deadline()
+		return nil
+	})
+	root.SetContextAction(func(ctx context.Context, args []string, opts map[string]any) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if err := root.Execute(nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if !hookSawDeadline {
+		t.Error("Context hook should observe the command timeout")
+	}
+}
+
+func TestExecuteParentTimeout(t *testing.T) {
+	root := NewCommand("root")
+	root.SetTimeout(20 * time.Millisecond)
+	leaf := NewCommand("leaf")
+	root.AddSubcommand(leaf)
+	leaf.SetContextAction(func(ctx context.Context, args []string, opts map[string]any) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if err := root.Execute([]string{"leaf"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the parent timeout to bound the leaf, got %v", err)
+	}
+
+	// Test that ExecuteWithHooksContext applies the command's own timeout
+	leaf.SetTimeout(20 * time.Millisecond)
+	if err := leaf.ExecuteWithHooksContext(context.Background(), nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestExecuteAsyncActionCancelled(t *testing.T) {
+	root := NewCommand("root")
+	root.SetAsyncAction(func(args []string, opts map[string]any) <-chan error {
+		// Never completes
+		return make(chan error)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- root.ExecuteContext(ctx, nil) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Async action should be abandoned when the context is done")
+	}
+}
@@ -0,0 +1,478 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultHelpWidth is used when neither the Help nor the output configuration
+// provides a width
+const defaultHelpWidth = 80
+
+// Help formats help text for a command (compatible with Commander.js Help).
+// Each step can be replaced by setting the matching function field, which
+// receives the Help so it can fall back to the default methods.
+type Help struct {
+	// HelpWidth wraps text to this many columns; zero uses the output width
+	HelpWidth       int
+	SortSubcommands bool
+	SortOptions     bool
+
+	// Overrides for the formatting steps
+	FormatHelpFunc            func(cmd *Command, helper *Help) string
+	CommandUsageFunc          func(cmd *Command) string
+	SubcommandTermFunc        func(cmd *Command) string
+	SubcommandDescriptionFunc func(cmd *Command) string
+	OptionTermFunc            func(option *Option) string
+	OptionDescriptionFunc     func(option *Option) string
+	ArgumentTermFunc          func(argument *Argument) string
+	ArgumentDescriptionFunc   func(argument *Argument) string
+	VisibleCommandsFunc       func(cmd *Command) []*Command
+	VisibleOptionsFunc        func(cmd *Command) []*Option
+	VisibleGlobalOptionsFunc  func(cmd *Command) []*Option
+	VisibleArgumentsFunc      func(cmd *Command) []*Argument
+}
+
+// NewHelp creates a help formatter with the default layout
+func NewHelp() *Help {
+	return &Help{}
+}
+
+// ConfigureHelp sets the help formatter used by the command and, through
+// CopyInheritedSettings, by subcommands created from it
+func (c *Command) ConfigureHelp(help *Help) *Command {
+	c.HelpConfiguration = help
+	return c
+}
+
+// CreateHelp returns a copy of the command's help formatter with its width
+// resolved from the output configuration
+func (c *Command) CreateHelp() *Help {
+	return c.createHelp(false)
+}
+
+// createHelp resolves the help width for stdout, or stderr when forErr is set
+func (c *Command) createHelp(forErr bool) *Help {
+	help := NewHelp()
+	if c.HelpConfiguration != nil {
+		*help = *c.HelpConfiguration
+	}
+
+	if help.HelpWidth <= 0 && c.OutputConfiguration != nil {
+		getWidth := c.OutputConfiguration.GetOutHelpWidth
+		if forErr {
+			getWidth = c.OutputConfiguration.GetErrHelpWidth
+		}
+		if getWidth != nil {
+			help.HelpWidth = getWidth()
+		}
+	}
+	if help.HelpWidth <= 0 {
+		help.HelpWidth = defaultHelpWidth
+	}
+	return help
+}
+
+// VisibleCommands returns the subcommands listed in help, including the help
+// command when one is active
+func (h *Help) VisibleCommands(cmd *Command) []*Command {
+	if h.VisibleCommandsFunc != nil {
+		return h.VisibleCommandsFunc(cmd)
+	}
+
+	visible := cmd.GetVisibleSubcommands()
+	if helpCmd := cmd.GetHelpCommand(); helpCmd != nil && !helpCmd.Hidden && !slices.Contains(visible, helpCmd) {
+		visible = append(visible, helpCmd)
+	}
+	if h.SortSubcommands {
+		slices.SortStableFunc(visible, func(a, b *Command) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+	}
+	return visible
+}
+
+// VisibleOptions returns the options listed in help, with the help option last
+func (h *Help) VisibleOptions(cmd *Command) []*Option {
+	if h.VisibleOptionsFunc != nil {
+		return h.VisibleOptionsFunc(cmd)
+	}
+
+	var visible []*Option
+	for _, opt := range cmd.Options {
+		if !opt.Hidden && opt != cmd.HelpOption {
+			visible = append(visible, opt)
+		}
+	}
+	if h.SortOptions {
+		slices.SortStableFunc(visible, func(a, b *Option) int {
+			return strings.Compare(optionSortKey(a), optionSortKey(b))
+		})
+	}
+	if cmd.HelpOption != nil && !cmd.HelpOption.Hidden && slices.Contains(cmd.Options, cmd.HelpOption) {
+		visible = append(visible, cmd.HelpOption)
+	}
+	return visible
+}
+
+// optionSortKey orders options by short flag, falling back to the long flag
+func optionSortKey(opt *Option) string {
+	if opt.Short != "" {
+		return opt.Short
+	}
+	return opt.Long
+}
+
+// VisibleGlobalOptions returns the global options inherited from ancestors,
+// listed under "Global Options" in a subcommand's help
+func (h *Help) VisibleGlobalOptions(cmd *Command) []*Option {
+	if h.VisibleGlobalOptionsFunc != nil {
+		return h.VisibleGlobalOptionsFunc(cmd)
+	}
+
+	var visible []*Option
+	for _, opt := range cmd.inheritedGlobalOptions() {
+		if !opt.Hidden {
+			visible = append(visible, opt)
+		}
+	}
+	if h.SortOptions {
+		slices.SortStableFunc(visible, func(a, b *Option) int {
+			return strings.Compare(optionSortKey(a), optionSortKey(b))
+		})
+	}
+	return visible
+}
+
+// VisibleArguments returns the arguments listed in help. Arguments are only
+// listed when at least one has a description.
+func (h *Help) VisibleArguments(cmd *Command) []*Argument {
+	if h.VisibleArgumentsFunc != nil {
+		return h.VisibleArgumentsFunc(cmd)
+	}
+
+	for _, arg := range cmd.Arguments {
+		if arg.Description != "" {
+			return cmd.Arguments
+		}
+	}
+	return nil
+}
+
+// SubcommandTerm returns the term for a subcommand, e.g. "deploy|d [options] <service>"
+func (h *Help) SubcommandTerm(cmd *Command) string {
+	if h.SubcommandTermFunc != nil {
+		return h.SubcommandTermFunc(cmd)
+	}
+
+	term := cmd.Name
+	if len(cmd.Aliases) > 0 {
+		term += "|" + cmd.Aliases[0]
+	}
+	// Like Commander.js, the help option alone does not add [options]
+	if slices.ContainsFunc(cmd.Options, func(opt *Option) bool { return opt != cmd.HelpOption }) {
+		term += " [options]"
+	}
+	for _, arg := range cmd.Arguments {
+		term += " " + argumentUsage(arg)
+	}
+	return term
+}
+
+// SubcommandDescription returns the summary of a subcommand, falling back to
+// its description
+func (h *Help) SubcommandDescription(cmd *Command) string {
+	if h.SubcommandDescriptionFunc != nil {
+		return h.SubcommandDescriptionFunc(cmd)
+	}
+	if cmd.Summary != "" {
+		return cmd.Summary
+	}
+	return cmd.Description
+}
+
+// OptionTerm returns the term for an option, which is its flags
+func (h *Help) OptionTerm(option *Option) string {
+	if h.OptionTermFunc != nil {
+		return h.OptionTermFunc(option)
+	}
+	// Options declared without a value placeholder show their value type
+	if option.expectsValue() && !strings.ContainsAny(option.Flags, "<[") {
+		if option.TypeName != "" {
+			return option.Flags + " <" + option.TypeName + ">"
+		}
+	}
+	return option.Flags
+}
+
+// OptionDescription returns the option description followed by its choices,
+// default and environment variable
+func (h *Help) OptionDescription(option *Option) string {
+	if h.OptionDescriptionFunc != nil {
+		return h.OptionDescriptionFunc(option)
+	}
+
+	var extra []string
+	if len(option.Choices) > 0 {
+		extra = append(extra, "choices: "+quoteChoices(option.Choices))
+	}
+	// Counts start at zero unless given another default
+	if showDefault(option.Default) && !(option.Type == OptionTypeCount && option.Default == 0) {
+		extra = append(extra, "default: "+formatHelpValue(option.Default))
+	}
+	if option.Env != "" {
+		extra = append(extra, "env: "+option.Env)
+	}
+	return appendExtraInfo(option.Description, extra)
+}
+
+// ArgumentTerm returns the term for an argument, which is its name
+func (h *Help) ArgumentTerm(argument *Argument) string {
+	if h.ArgumentTermFunc != nil {
+		return h.ArgumentTermFunc(argument)
+	}
+	return argument.Name
+}
+
+// ArgumentDescription returns the argument description followed by its
+// value type, choices and default
+func (h *Help) ArgumentDescription(argument *Argument) string {
+	if h.ArgumentDescriptionFunc != nil {
+		return h.ArgumentDescriptionFunc(argument)
+	}
+
+	var extra []string
+	if argument.TypeName != "" {
+		extra = append(extra, "type: "+argument.TypeName)
+	}
+	if len(argument.Choices) > 0 {
+		extra = append(extra, "choices: "+quoteChoices(argument.Choices))
+	}
+	if showDefault(argument.Default) {
+		extra = append(extra, "default: "+formatHelpValue(argument.Default))
+	}
+	return appendExtraInfo(argument.Description, extra)
+}
+
+// CommandUsage returns the usage line without the "Usage:" prefix
+func (h *Help) CommandUsage(cmd *Command) string {
+	if h.CommandUsageFunc != nil {
+		return h.CommandUsageFunc(cmd)
+	}
+
+	name := cmd.GetFullName()
+	if len(cmd.Aliases) > 0 {
+		name += "|" + cmd.Aliases[0]
+	}
+	if cmd.Usage != "" {
+		return name + " " + cmd.Usage
+	}
+
+	var parts []string
+	if len(cmd.Options) > 0 {
+		parts = append(parts, "[options]")
+	}
+	if len(cmd.Subcommands) > 0 {
+		parts = append(parts, "[command]")
+	}
+	for _, arg := range cmd.Arguments {
+		parts = append(parts, argumentUsage(arg))
+	}
+	if len(parts) == 0 {
+		return name
+	}
+	return name + " " + strings.Join(parts, " ")
+}
+
+// PadWidth returns the width of the longest term across all sections
+func (h *Help) PadWidth(cmd *Command) int {
+	width := 0
+	for _, sub := range h.VisibleCommands(cmd) {
+		width = max(width, utf8.RuneCountInString(h.SubcommandTerm(sub)))
+	}
+	for _, opt := range h.VisibleOptions(cmd) {
+		width = max(width, utf8.RuneCountInString(h.OptionTerm(opt)))
+	}
+	for _, opt := range h.VisibleGlobalOptions(cmd) {
+		width = max(width, utf8.RuneCountInString(h.OptionTerm(opt)))
+	}
+	for _, arg := range h.VisibleArguments(cmd) {
+		width = max(width, utf8.RuneCountInString(h.ArgumentTerm(arg)))
+	}
+	return width
+}
+
+// Wrap wraps text to width, indenting continuation lines by indent. Text that
+// is already manually indented, or a column narrower than minColumnWidth, is
+// returned unchanged.
+func (h *Help) Wrap(text string, width, indent, minColumnWidth int) string {
+	if strings.Contains(text, "\n ") || strings.Contains(text, "\n\t") {
+		return text
+	}
+	columnWidth := width - indent
+	if columnWidth < minColumnWidth {
+		return text
+	}
+
+	padding := strings.Repeat(" ", indent)
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > columnWidth {
+				lines = append(lines, line)
+				line = word
+			} else if line == "" {
+				line = word
+			} else {
+				line += " " + word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"+padding)
+}
+
+// FormatHelp renders the complete help text for cmd
+func (h *Help) FormatHelp(cmd *Command) string {
+	if h.FormatHelpFunc != nil {
+		return h.FormatHelpFunc(cmd, h)
+	}
+
+	const itemIndent = 2
+	const itemSeparator = 2
+	termWidth := h.PadWidth(cmd)
+	helpWidth := h.HelpWidth
+	if helpWidth <= 0 {
+		helpWidth = defaultHelpWidth
+	}
+
+	formatItem := func(term, description string) string {
+		if description == "" {
+			return strings.Repeat(" ", itemIndent) + term
+		}
+		padded := term + strings.Repeat(" ", termWidth+itemSeparator-utf8.RuneCountInString(term))
+		indent := itemIndent + termWidth + itemSeparator
+		return strings.Repeat(" ", itemIndent) + padded + h.Wrap(description, helpWidth, indent, 40)
+	}
+
+	var b strings.Builder
+	b.WriteString("Usage: " + h.CommandUsage(cmd) + "\n")
+
+	if cmd.Description != "" {
+		b.WriteString("\n" + h.Wrap(cmd.Description, helpWidth, 0, 40) + "\n")
+	}
+
+	writeSection := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		b.WriteString("\n" + title + ":\n")
+		for _, item := range items {
+			b.WriteString(item + "\n")
+		}
+	}
+
+	var items []string
+	for _, arg := range h.VisibleArguments(cmd) {
+		items = append(items, formatItem(h.ArgumentTerm(arg), h.ArgumentDescription(arg)))
+	}
+	writeSection("Arguments", items)
+
+	// Grouped options get a section of their own after the other options
+	items = nil
+	groupItems := make(map[*OptionGroup][]string)
+	for _, opt := range h.VisibleOptions(cmd) {
+		item := formatItem(h.OptionTerm(opt), h.OptionDescription(opt))
+		if group := cmd.optionGroup(opt); group != nil {
+			groupItems[group] = append(groupItems[group], item)
+		} else {
+			items = append(items, item)
+		}
+	}
+	writeSection("Options", items)
+	for _, group := range cmd.OptionGroups {
+		if len(groupItems[group]) == 0 {
+			continue
+		}
+		if group.Description != "" {
+			groupItems[group] = append([]string{strings.Repeat(" ", itemIndent) +
+				h.Wrap(group.Description, helpWidth, itemIndent, 40)}, groupItems[group]...)
+		}
+		writeSection(group.Name, groupItems[group])
+	}
+
+	items = nil
+	for _, opt := range h.VisibleGlobalOptions(cmd) {
+		items = append(items, formatItem(h.OptionTerm(opt), h.OptionDescription(opt)))
+	}
+	writeSection("Global Options", items)
+
+	items = nil
+	for _, sub := range h.VisibleCommands(cmd) {
+		items = append(items, formatItem(h.SubcommandTerm(sub), h.SubcommandDescription(sub)))
+	}
+	writeSection("Commands", items)
+
+	return b.String()
+}
+
+// argumentUsage returns the bracketed form of an argument, e.g. <file> or [files...]
+func argumentUsage(arg *Argument) string {
+	name := arg.Name
+	if arg.Variadic {
+		name += "..."
+	}
+	if arg.Required {
+		return "<" + name + ">"
+	}
+	return "[" + name + "]"
+}
+
+// showDefault reports whether a default value is worth showing in help;
+// false booleans and empty lists are the implicit defaults
+func showDefault(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case []any:
+		return len(v) > 0
+	case []string:
+		return len(v) > 0
+	}
+	return true
+}
+
+// formatHelpValue renders a value the way Commander.js does, as JSON
+func formatHelpValue(value any) string {
+	if data, err := json.Marshal(value); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// quoteChoices renders choices as a comma-separated list of quoted values
+func quoteChoices(choices []string) string {
+	quoted := make([]string, len(choices))
+	for i, choice := range choices {
+		quoted[i] = fmt.Sprintf("%q", choice)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// appendExtraInfo appends "(extra, info)" to a description
+func appendExtraInfo(description string, extra []string) string {
+	if len(extra) == 0 {
+		return description
+	}
+	info := "(" + strings.Join(extra, ", ") + ")"
+	if description == "" {
+		return info
+	}
+	return description + " " + info
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHelpFormatterLayout(t *testing.T) {
+	root := NewCommand("app")
+	root.Description = "My application"
+	root.AddOption(CreateChoiceOption("-e, --env <name>", "target environment", []string{"dev", "prod"}).SetDefault("dev").SetEnv("APP_ENV"))
+	root.AddOption(NewBooleanOption("-v, --verbose", "verbose output"))
+	root.AddOption(NewOption("--secret <token>", "api token").SetHidden(true))
+
+	deploy := NewCommand("deploy")
+	deploy.Summary = "deploy a service"
+	deploy.Description = "Deploy a service to the selected environment"
+	deploy.AddAlias("d")
+	deploy.AddArgument(NewArgument("<service>", "service to deploy"))
+	root.AddSubcommand(deploy)
+
+	expected := `Usage: app [options] [command]
+
+My application
+
+Options:
+  -e, --env <name>    target environment (choices: "dev", "prod", default:
+                      "dev", env: APP_ENV)
+  -v, --verbose       verbose output
+  -h, --help          display help for command
+
+Commands:
+  deploy|d <service>  deploy a service
+  help [command]      display help for command
+`
+	if help := root.GenerateHelp(); help != expected {
+		t.Errorf("Unexpected help:\n%s\nwant:\n%s", help, expected)
+	}
+}
+
+func TestHelpFormatterWrapsToOutputWidth(t *testing.T) {
+	root := NewCommand("app")
+	root.AddOption(NewOption("--region <name>", "region to deploy into when no region is configured for the target environment"))
+	root.ConfigureOutput(&OutputConfiguration{GetOutHelpWidth: func() int { return 60 }})
+
+	help := root.GenerateHelp()
+	for _, line := range strings.Split(help, "\n") {
+		if len(line) > 60 {
+			t.Errorf("Line exceeds help width: %q", line)
+		}
+	}
+	if !strings.Contains(help, "\n                   ") {
+		t.Errorf("Expected wrapped lines to be indented to the description column, got:\n%s", help)
+	}
+
+	// Columns narrower than the minimum are left unwrapped
+	root.ConfigureHelp(&Help{HelpWidth: 30})
+	if !strings.Contains(root.GenerateHelp(), "region to deploy into when no region is configured") {
+		t.Errorf("Expected narrow help to be left unwrapped, got:\n%s", root.GenerateHelp())
+	}
+}
+
+func TestHelpFormatterOverridesAreInherited(t *testing.T) {
+	root := NewCommand("app")
+	root.ConfigureHelp(&Help{
+		SortSubcommands: true,
+		SubcommandTermFunc: func(cmd *Command) string {
+			return strings.ToUpper(cmd.Name)
+		},
+	})
+
+	sub := NewCommand("zeta")
+	sub.CopyInheritedSettings(root)
+	sub.AddSubcommand(NewCommand("beta"))
+	sub.AddSubcommand(NewCommand("alpha"))
+	root.AddSubcommand(sub)
+
+	help := sub.GenerateHelp()
+	if !strings.Contains(help, "ALPHA") || strings.Index(help, "ALPHA") > strings.Index(help, "BETA") {
+		t.Errorf("Expected inherited sorted, overridden terms, got:\n%s", help)
+	}
+
+	// Test that customising the child leaves the parent's help alone
+	sub.HelpConfiguration.SortSubcommands = false
+	sub.HelpConfiguration.SubcommandTermFunc = nil
+	if !root.HelpConfiguration.SortSubcommands || root.HelpConfiguration.SubcommandTermFunc == nil {
+		t.Error("Expected the parent's help configuration to be unchanged")
+	}
+
+	root.ConfigureHelp(&Help{FormatHelpFunc: func(cmd *Command, helper *Help) string {
+		return "custom usage: " + helper.CommandUsage(cmd) + "\n"
+	}})
+	if help := root.GenerateHelp(); help != "custom usage: app [options] [command]\n" {
+		t.Errorf("Unexpected custom help %q", help)
+	}
+}
+
+func TestHelpOptionGroups(t *testing.T) {
+	root := NewCommand("export")
+	root.AddOption(NewBooleanOption("-v, --verbose", "verbose output"))
+	root.AddOptionGroup(NewOptionGroup("Output Format", "Choose at most one format").SetExclusive(true).
+		AddOption(NewBooleanOption("--json", "write JSON")).
+		AddOption(NewBooleanOption("--yaml", "write YAML")))
+	root.AddOptionGroup(NewOptionGroup("Hidden Group", "").
+		AddOption(NewBooleanOption("--secret", "secret").SetHidden(true)))
+
+	expected := `Usage: export [options]
+
+Options:
+  -v, --verbose  verbose output
+  -h, --help     display help for command
+
+Output Format:
+  Choose at most one format
+  --json         write JSON
+  --yaml         write YAML
+`
+	if help := root.GenerateHelp(); help != expected {
+		t.Errorf("Unexpected help:\n%s\nwant:\n%s", help, expected)
+	}
+}
+
+func TestHelpGlobalOptions(t *testing.T) {
+	root := NewCommand("app")
+	root.AddGlobalOption(NewBooleanOption("-v, --verbose", "verbose output"))
+	root.AddGlobalOption(NewBooleanOption("--trace", "trace").SetHidden(true))
+	root.AddOption(NewBooleanOption("--local", "root only"))
+	sub := NewCommand("build")
+	sub.AddOption(NewBooleanOption("-w, --watch", "rebuild on change"))
+	root.AddSubcommand(sub)
+
+	expected := `Usage: app build [options]
+
+Options:
+  -w, --watch    rebuild on change
+  -h, --help     display help for command
+
+Global Options:
+  -v, --verbose  verbose output
+`
+	if help := sub.GenerateHelp(); help != expected {
+		t.Errorf("Unexpected help:\n%s\nwant:\n%s", help, expected)
+	}
+	if help := root.GenerateHelp(); strings.Contains(help, "Global Options") {
+		t.Errorf("Global options are local to the declaring command, got:\n%s", help)
+	}
+}
@@ -19,6 +19,24 @@ const (
 	OptionTypeString
 	OptionTypeNumber
 	OptionTypeVariadic
+	OptionTypeCount
+)
+
+// AccumulationMode controls how repeated uses of an option combine
+type AccumulationMode int
+
+const (
+	// AccumulateDefault keeps the option's natural behaviour: variadic options
+	// and custom parsers accumulate through previous, others keep the last value
+	AccumulateDefault AccumulationMode = iota
+	// AccumulateLastWins keeps the value from the last use of the option
+	AccumulateLastWins
+	// AccumulateFirstWins keeps the value from the first use of the option
+	AccumulateFirstWins
+	// AccumulateAppend collects the value from every use into a list
+	AccumulateAppend
+	// AccumulateErrorOnRepeat rejects using the option more than once
+	AccumulateErrorOnRepeat
 )
 
 // Option represents a command-line option
@@ -30,6 +48,7 @@ type Option struct {
 	Default     any
 	Choices     []string
 	Parser      OptionParser
+	TypeName    string
 	Short       string
 	Long        string
 
@@ -47,8 +66,20 @@ type Option struct {
 	Coercion  OptionParser
 
 	// Conflict detection
-	Conflicts []string
-	Implies   []string
+	Conflicts     []string
+	Implies       []string
+	ImpliedValues map[string]any
+
+	// Value validation and shell completion
+	Validator func(any) error
+	Completer *Completer
+
+	// Repeated use
+	Accumulation AccumulationMode
+	ResetDefault bool
+
+	// Global options are accepted by every subcommand of the declaring command
+	Global bool
 }
 
 // NewOption creates a new option with the given flags and description
@@ -83,6 +114,15 @@ func NewVariadicOption(flags, description string) *Option {
 	return option
 }
 
+// NewCountOption creates an option counting how often it is used, so -vvv
+// yields 3. An explicit value such as --verbose=2 sets the count.
+func NewCountOption(flags, description string) *Option {
+	option := NewOption(flags, description)
+	option.Type = OptionTypeCount
+	option.Default = 0
+	return option
+}
+
 // parseFlags parses the flags string to extract short and long flag names
 func (o *Option) parseFlags() {
 	parts := strings.Split(o.Flags, ",")
@@ -124,13 +164,12 @@ func (o *Option) parseArgumentSpecification(spec string) {
 		o.Type = OptionTypeVariadic
 	}
 
-	// Check if argument is optional
+	// Check if the option value is optional. A <value> spec only means the flag
+	// needs a value when used; whether the option itself is mandatory is Required.
 	if strings.HasPrefix(spec, "[") && strings.HasSuffix(spec, "]") {
 		o.Optional = true
-		o.Required = false
 	} else if strings.HasPrefix(spec, "<") && strings.HasSuffix(spec, ">") {
 		o.Optional = false
-		o.Required = true
 	}
 }
 
@@ -164,6 +203,17 @@ func (o *Option) SetParser(parser OptionParser) *Option {
 	return o
 }
 
+// SetValueType parses the option with the value type's parser, names its
+// value in help and completes it with the type's completer, if any
+func (o *Option) SetValueType(valueType ValueType) *Option {
+	o.Parser = valueType.Parser
+	o.TypeName = valueType.Name
+	if valueType.Completer != nil {
+		o.Completer = valueType.Completer
+	}
+	return o
+}
+
 // SetEnv sets the environment variable name for this option
 func (o *Option) SetEnv(env string) *Option {
 	o.Env = env
@@ -182,6 +232,69 @@ func (o *Option) SetImplies(implies []string) *Option {
 	return o
 }
 
+// SetImpliedValues sets the values other options take when this option is used,
+// like Commander.js implies({key: value})
+func (o *Option) SetImpliedValues(values map[string]any) *Option {
+	o.ImpliedValues = values
+	return o
+}
+
+// SetValidator sets a function that validates each parsed value
+func (o *Option) SetValidator(validator func(any) error) *Option {
+	o.Validator = validator
+	return o
+}
+
+// SetCompleter sets how shell completion suggests values for the option
+func (o *Option) SetCompleter(completer *Completer) *Option {
+	o.Completer = completer
+	return o
+}
+
+// SetFileValidator validates each value with ValidateFileExists and
+// completes the option with file names
+func (o *Option) SetFileValidator() *Option {
+	o.Validator = ValidateFileExists
+	o.Completer = FileCompleter()
+	return o
+}
+
+// SetDirectoryValidator validates each value with ValidateDirectoryExists
+// and completes the option with directory names
+func (o *Option) SetDirectoryValidator() *Option {
+	o.Validator = ValidateDirectoryExists
+	o.Completer = DirectoryCompleter()
+	return o
+}
+
+// SetGlobal makes the option available to all subcommands, before or after
+// their name on the command line
+func (o *Option) SetGlobal(global bool) *Option {
+	o.Global = global
+	return o
+}
+
+// SetAccumulation sets how repeated uses of the option combine
+func (o *Option) SetAccumulation(mode AccumulationMode) *Option {
+	o.Accumulation = mode
+	return o
+}
+
+// SetResetDefault makes the first use of the option on the command line
+// start from nothing instead of the default, so a custom parser receives nil
+// as previous and a count starts from zero. Variadic and appended values
+// always replace their default.
+func (o *Option) SetResetDefault(reset bool) *Option {
+	o.ResetDefault = reset
+	return o
+}
+
+// expectsValue reports whether the option takes a value rather than being a
+// plain flag
+func (o *Option) expectsValue() bool {
+	return o.Type != OptionTypeBoolean && o.Type != OptionTypeCount
+}
+
 // SetHidden marks the option as hidden from help
 func (o *Option) SetHidden(hidden bool) *Option {
 	o.Hidden = hidden
@@ -190,6 +303,24 @@ func (o *Option) SetHidden(hidden bool) *Option {
 
 // ParseValue parses a string value using the option's parser or default parsing
 func (o *Option) ParseValue(value string, previous any) (any, error) {
+	parsed, err := o.parseValue(value, previous)
+	if err != nil || o.Validator == nil {
+		return parsed, err
+	}
+
+	// Validate the newly parsed element of variadic values
+	item := parsed
+	if items, ok := parsed.([]any); ok && o.Variadic && len(items) > 0 {
+		item = items[len(items)-1]
+	}
+	if err := o.Validator(item); err != nil {
+		return nil, fmt.Errorf("invalid value for option '%s': %v", o.Flags, err)
+	}
+	return parsed, nil
+}
+
+// parseValue parses a string value before validation
+func (o *Option) parseValue(value string, previous any) (any, error) {
 	// Use custom parser if provided
 	if o.Parser != nil {
 		return o.Parser(value, previous)
@@ -210,6 +341,8 @@ func (o *Option) ParseValue(value string, previous any) (any, error) {
 		return DefaultNumberParser(value, previous)
 	case OptionTypeVariadic:
 		return o.parseVariadicValue(value, previous)
+	case OptionTypeCount:
+		return DefaultIntParser(value, previous)
 	default:
 		return value, nil
 	}
@@ -259,6 +392,22 @@ func (o *Option) Matches(flag string) bool {
 	return false
 }
 
+// longFlags returns the long flags accepted for the option, e.g. --color and
+// --no-color; an option declared only as --no-color does not list --color
+func (o *Option) longFlags() []string {
+	if o.Long == "" {
+		return nil
+	}
+	if !o.Negatable {
+		return []string{"--" + o.Long}
+	}
+	flags := []string{"--no-" + o.Long}
+	if strings.Contains(o.Flags, "--"+o.Long) {
+		flags = append(flags, "--"+o.Long)
+	}
+	return flags
+}
+
 // IsNegated checks if the flag is the negated version of this option
 func (o *Option) IsNegated(flag string) bool {
 	return o.Negatable && o.Long != "" && flag == "no-"+o.Long
@@ -306,6 +455,9 @@ func (o *Option) Validate() error {
 	if o.Type == OptionTypeBoolean && len(o.Choices) > 0 {
 		return fmt.Errorf("boolean options cannot have choices")
 	}
+	if o.Type == OptionTypeCount && len(o.Choices) > 0 {
+		return fmt.Errorf("count options cannot have choices")
+	}
 
 	return nil
 }
@@ -358,13 +510,6 @@ func (o *Option) ProcessOptionValue(value string, previous any, isNegated bool)
 		return true, nil
 	}
 
-	// Handle environment variable resolution (but don't override explicit CLI values)
-	if o.Env != "" && value == "" {
-		if envValue := os.Getenv(o.Env); envValue != "" {
-			value = envValue
-		}
-	}
-
 	// Use preset value if no value provided and preset exists
 	if value == "" && o.Preset != nil {
 		return o.Preset, nil
@@ -418,6 +563,35 @@ func (o *Option) GetImpliedOptions() []string {
 	return o.Implies
 }
 
+// GetImpliedValues returns the implied key/value pairs for this option.
+// Names listed in Implies imply true; ImpliedValues entries take precedence.
+func (o *Option) GetImpliedValues() map[string]any {
+	if len(o.Implies) == 0 && len(o.ImpliedValues) == 0 {
+		return nil
+	}
+
+	values := make(map[string]any, len(o.Implies)+len(o.ImpliedValues))
+	for _, name := range o.Implies {
+		values[name] = true
+	}
+	for name, value := range o.ImpliedValues {
+		values[name] = value
+	}
+	return values
+}
+
+// displayName returns the flag users type for this option, preferring the
+// long form, as --no-<long> when the negated form of a negatable option is meant
+func (o *Option) displayName(negated bool) string {
+	if o.Long != "" {
+		if negated && o.Negatable {
+			return "--no-" + o.Long
+		}
+		return "--" + o.Long
+	}
+	return "-" + o.Short
+}
+
 // CreateNegatableOption creates a negatable boolean option
 func CreateNegatableOption(flags, description string) *Option {
 	option := NewBooleanOption(flags, description)
@@ -726,13 +900,15 @@ func (ap *AdvancedOptionParser) ParseOptionValue(option *Option, value string, p
 	return option.ParseValue(value, previous)
 }
 
-// OptionGroup represents a group of related options
+// OptionGroup represents a group of related options. Exclusive and Required
+// together require exactly one option of the group.
 type OptionGroup struct {
 	Name        string
 	Description string
 	Options     []*Option
 	Exclusive   bool // If true, only one option in the group can be set
 	Required    bool // If true, at least one option in the group must be set
+	AllOrNone   bool // If true, the options must be set together or not at all
 }
 
 // NewOptionGroup creates a new option group
@@ -764,34 +940,65 @@ func (og *OptionGroup) SetRequired(required bool) *OptionGroup {
 	return og
 }
 
-// Validate validates the option group constraints
+// SetAllOrNone requires the options of the group to be used together
+func (og *OptionGroup) SetAllOrNone(allOrNone bool) *OptionGroup {
+	og.AllOrNone = allOrNone
+	return og
+}
+
+// Validate validates the option group constraints against the options
+// present in values
 func (og *OptionGroup) Validate(values map[string]any) error {
-	setOptions := make([]*Option, 0)
+	err := og.validateSet(func(option *Option) (any, bool) {
+		value, exists := values[getOptionKey(option)]
+		return value, exists
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
 
-	// Find which options in the group are set
+// validateSet checks the group constraints, with lookup returning the value
+// of each option and whether it was used, and returns an OptionGroupError
+// naming the flags involved
+func (og *OptionGroup) validateSet(lookup func(*Option) (any, bool)) *OptionGroupError {
+	var set, unset []string
 	for _, option := range og.Options {
-		key := getOptionKey(option)
-		if _, exists := values[key]; exists {
-			setOptions = append(setOptions, option)
+		if value, isSet := lookup(option); isSet {
+			set = append(set, option.displayName(value == false))
+		} else {
+			unset = append(unset, option.displayName(false))
 		}
 	}
-
-	// Check exclusive constraint
-	if og.Exclusive && len(setOptions) > 1 {
-		optionNames := make([]string, len(setOptions))
-		for i, opt := range setOptions {
-			optionNames[i] = opt.Flags
-		}
-		return fmt.Errorf("options in group '%s' are mutually exclusive, but multiple were set: %s",
-			og.Name, strings.Join(optionNames, ", "))
+	all := append(append([]string(nil), set...), unset...)
+
+	switch {
+	case og.Exclusive && len(set) > 1:
+		return NewOptionGroupError("commander.conflictingOption",
+			fmt.Sprintf("option '%s' cannot be used with option '%s' (group '%s' allows only one of %s)",
+				set[0], set[1], og.Name, quoteFlags(all)), og.Name, set)
+	case og.Exclusive && og.Required && len(set) == 0:
+		return NewOptionGroupError("commander.missingOption",
+			fmt.Sprintf("exactly one of %s is required (group '%s')", quoteFlags(all), og.Name), og.Name, all)
+	case og.Required && len(set) == 0:
+		return NewOptionGroupError("commander.missingOption",
+			fmt.Sprintf("at least one of %s is required (group '%s')", quoteFlags(all), og.Name), og.Name, all)
+	case og.AllOrNone && len(set) > 0 && len(unset) > 0:
+		return NewOptionGroupError("commander.missingOption",
+			fmt.Sprintf("%s must be used together with %s (group '%s')", quoteFlags(set), quoteFlags(unset), og.Name),
+			og.Name, unset)
 	}
+	return nil
+}
 
-	// Check required constraint
-	if og.Required && len(setOptions) == 0 {
-		return fmt.Errorf("at least one option from group '%s' is required", og.Name)
+// quoteFlags formats flags as a quoted, comma-separated list
+func quoteFlags(flags []string) string {
+	quoted := make([]string, len(flags))
+	for i, flag := range flags {
+		quoted[i] = "'" + flag + "'"
 	}
-
-	return nil
+	return strings.Join(quoted, ", ")
 }
 
 // EnhancedOptionProcessor extends OptionProcessor with advanced features
@@ -1029,6 +1236,8 @@ func (cop *ContextualOptionProcessor) GetOptionProcessingSummary() map[string]an
 			typeCounts["number"]++
 		case OptionTypeVariadic:
 			typeCounts["variadic"]++
+		case OptionTypeCount:
+			typeCounts["count"]++
 		}
 	}
 	summary["optionTypes"] = typeCounts
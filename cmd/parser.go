@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
 )
 
@@ -11,22 +14,134 @@ type ParsedCommand struct {
 	Options   map[string]any
 	Arguments []any
 	Unknown   []string
+
+	// ValueSources records where each option value came from
+	ValueSources map[string]ValueSource
+
+	// Parent holds the parse result of the parent command when a subcommand was dispatched
+	Parent *ParsedCommand
+
+	// PassThroughArgs holds the original command-line arguments left once
+	// option processing stopped, beyond those taken by declared arguments
+	PassThroughArgs []string
 }
 
-// Parser handles command-line argument parsing
+// ValueSource identifies where a parsed option value came from
+type ValueSource string
+
+const (
+	ValueSourceDefault ValueSource = "default"
+	ValueSourceEnv     ValueSource = "env"
+	ValueSourceConfig  ValueSource = "config"
+	ValueSourceCLI     ValueSource = "cli"
+	ValueSourceImplied ValueSource = "implied"
+)
+
+// GetOptionValueSource returns where the option value for key came from, or
+// an empty source if the option has no value (Commander.js getOptionValueSource)
+func (pc *ParsedCommand) GetOptionValueSource(key string) ValueSource {
+	if _, exists := pc.Options[key]; !exists {
+		return ""
+	}
+	return pc.ValueSources[key]
+}
+
+// setOption stores an option value along with its source
+func (pc *ParsedCommand) setOption(key string, value any, source ValueSource) {
+	if pc.ValueSources == nil {
+		pc.ValueSources = make(map[string]ValueSource)
+	}
+	pc.Options[key] = value
+	pc.ValueSources[key] = source
+}
+
+// optionResult returns the result storing option: for a global option the
+// result of the command declaring it, otherwise pc itself
+func (pc *ParsedCommand) optionResult(option *Option) *ParsedCommand {
+	if option.Global {
+		for r := pc; r != nil; r = r.Parent {
+			if r.Command != nil && slices.Contains(r.Command.Options, option) {
+				return r
+			}
+		}
+	}
+	return pc
+}
+
+// OptsWithGlobals returns the options of the command merged with those of
+// its ancestors. A value the user set, from the command line, env or config,
+// wins over a default; between values of equal standing the nearest command
+// wins.
+func (pc *ParsedCommand) OptsWithGlobals() map[string]any {
+	merged := make(map[string]any)
+	userSet := make(map[string]bool)
+	for r := pc; r != nil; r = r.Parent {
+		for key, value := range r.Options {
+			if _, exists := merged[key]; exists && (userSet[key] || !r.isUserSet(key)) {
+				continue
+			}
+			merged[key] = value
+			userSet[key] = r.isUserSet(key)
+		}
+	}
+	return merged
+}
+
+// isUserSet returns true if the option has a value that did not come from its default
+func (pc *ParsedCommand) isUserSet(key string) bool {
+	if _, exists := pc.Options[key]; !exists {
+		return false
+	}
+	source, tracked := pc.ValueSources[key]
+	return tracked && source != ValueSourceDefault
+}
+
+// Parser handles command-line argument parsing. Options and operands may be
+// interspersed unless StopAtFirstPositional selects POSIX-style parsing, where
+// the first operand ends option processing; StopAtFirstUnknown likewise stops
+// at the first unknown option. The arguments from the stop point on fill the
+// declared arguments and the rest are kept unparsed in PassThroughArgs.
 type Parser struct {
-	AllowUnknownOptions bool
-	StopAtFirstUnknown  bool
+	AllowUnknownOptions   bool
+	StopAtFirstUnknown    bool
+	StopAtFirstPositional bool
 
 	// Advanced parsing options
 	EnablePositionalOptions     bool
 	PassThroughOptions          bool
 	CombineFlagAndOptionalValue bool
 
+	// AllowAbbreviations accepts unique prefixes of long options and
+	// subcommand names, e.g. --verb for --verbose and dep for deploy
+	AllowAbbreviations bool
+
+	// CollectErrors keeps parsing past missing required options and
+	// arguments, invalid option and argument values and conflicts, and
+	// reports them all together in an AggregateError
+	CollectErrors bool
+
 	// Enhanced parsing configuration
 	UnknownOptionHandler  func(option string, value string) error
 	ExcessArgumentHandler func(args []string) error
 	PositionalOptionMap   map[int]string // Maps position to option name
+
+	// Configuration file loaded for the current parse
+	configPath   string
+	configValues map[string]any
+
+	// Arguments of the current parse and where each option was given, used
+	// to locate errors
+	args            []string
+	optionPositions map[*Option]int
+
+	// Errors collected in the current parse, and the arguments whose values
+	// were invalid
+	errs             []error
+	invalidArguments map[*Argument]bool
+
+	// Results created in the current parse, whose opened files are closed
+	// if it fails
+	results []*ParsedCommand
 }
 
 // NewParser creates a new parser with default settings
@@ -34,9 +149,11 @@ func NewParser() *Parser {
 	return &Parser{
 		AllowUnknownOptions:         false,
 		StopAtFirstUnknown:          false,
+		StopAtFirstPositional:       false,
 		EnablePositionalOptions:     false,
 		PassThroughOptions:          false,
 		CombineFlagAndOptionalValue: true,
+		CollectErrors:               false,
 		PositionalOptionMap:         make(map[int]string),
 	}
 }
@@ -64,6 +181,8 @@ type Token struct {
 	Type  TokenType
 	Value string
 	Raw   string
+	// Index is the position of the command-line argument the token came from
+	Index int
 }
 
 // TokenType represents the type of a command-line token
@@ -85,10 +204,10 @@ func (p *Parser) Tokenize(args []string, cmd *Command) []Token {
 	for i, arg := range args {
 		if arg == "--" {
 			// Double dash - everything after is arguments
-			tokens = append(tokens, Token{Type: TokenDoubleDash, Value: arg, Raw: arg})
+			tokens = append(tokens, Token{Type: TokenDoubleDash, Value: arg, Raw: arg, Index: i})
 			// Add remaining args as arguments
 			for j := i + 1; j < len(args); j++ {
-				tokens = append(tokens, Token{Type: TokenArgument, Value: args[j], Raw: args[j]})
+				tokens = append(tokens, Token{Type: TokenArgument, Value: args[j], Raw: args[j], Index: j})
 			}
 			break
 		} else if strings.HasPrefix(arg, "--") {
@@ -97,17 +216,17 @@ func (p *Parser) Tokenize(args []string, cmd *Command) []Token {
 				// --flag=value format
 				flag := arg[2:idx]
 				value := arg[idx+1:]
-				tokens = append(tokens, Token{Type: TokenLongOption, Value: flag, Raw: arg})
-				tokens = append(tokens, Token{Type: TokenOptionValue, Value: value, Raw: value})
+				tokens = append(tokens, Token{Type: TokenLongOption, Value: flag, Raw: arg, Index: i})
+				tokens = append(tokens, Token{Type: TokenOptionValue, Value: value, Raw: value, Index: i})
 			} else {
 				// --flag format
-				tokens = append(tokens, Token{Type: TokenLongOption, Value: arg[2:], Raw: arg})
+				tokens = append(tokens, Token{Type: TokenLongOption, Value: arg[2:], Raw: arg, Index: i})
 			}
 		} else if strings.HasPrefix(arg, "-") && len(arg) > 1 {
 			// Short option(s)
 			if len(arg) == 2 {
 				// Single short option: -f
-				tokens = append(tokens, Token{Type: TokenShortOption, Value: arg[1:], Raw: arg})
+				tokens = append(tokens, Token{Type: TokenShortOption, Value: arg[1:], Raw: arg, Index: i})
 			} else {
 				// Multiple short options or short option with value: -abc or -fvalue
 				flags := arg[1:]
@@ -116,28 +235,31 @@ func (p *Parser) Tokenize(args []string, cmd *Command) []Token {
 				if p.canParseAsMultipleFlags(flags, cmd) {
 					// Parse as multiple boolean flags: -abc -> -a -b -c
 					for _, flag := range flags {
-						tokens = append(tokens, Token{Type: TokenShortOption, Value: string(flag), Raw: "-" + string(flag)})
+						tokens = append(tokens, Token{Type: TokenShortOption, Value: string(flag), Raw: "-" + string(flag), Index: i})
 					}
 				} else {
 					// Check if first character is a valid option that takes a value
 					firstFlag := string(flags[0])
 					option := cmd.FindOption(firstFlag)
+					if option == nil {
+						option = cmd.findGlobalOption(firstFlag)
+					}
 					if option != nil && option.Type != OptionTypeBoolean {
 						// Parse as flag with value: -fvalue -> -f value
 						value := flags[1:]
-						tokens = append(tokens, Token{Type: TokenShortOption, Value: firstFlag, Raw: "-" + firstFlag})
-						tokens = append(tokens, Token{Type: TokenOptionValue, Value: value, Raw: value})
+						tokens = append(tokens, Token{Type: TokenShortOption, Value: firstFlag, Raw: "-" + firstFlag, Index: i})
+						tokens = append(tokens, Token{Type: TokenOptionValue, Value: value, Raw: value, Index: i})
 					} else {
 						// Fallback: treat as multiple flags even if some are unknown
 						for _, flag := range flags {
-							tokens = append(tokens, Token{Type: TokenShortOption, Value: string(flag), Raw: "-" + string(flag)})
+							tokens = append(tokens, Token{Type: TokenShortOption, Value: string(flag), Raw: "-" + string(flag), Index: i})
 						}
 					}
 				}
 			}
 		} else {
 			// Regular argument
-			tokens = append(tokens, Token{Type: TokenArgument, Value: arg, Raw: arg})
+			tokens = append(tokens, Token{Type: TokenArgument, Value: arg, Raw: arg, Index: i})
 		}
 	}
 
@@ -149,7 +271,7 @@ func (p *Parser) canParseAsMultipleFlags(flags string, cmd *Command) bool {
 	// Check each character to see if it's a valid short option
 	for _, char := range flags {
 		flag := string(char)
-		found := false
+		found := cmd.findGlobalOption(flag) != nil
 		for _, option := range cmd.Options {
 			if option.Short == flag {
 				found = true
@@ -165,34 +287,68 @@ func (p *Parser) canParseAsMultipleFlags(flags string, cmd *Command) bool {
 
 // ParseCommand parses command-line arguments against a command structure
 func (p *Parser) ParseCommand(cmd *Command, args []string) (*ParsedCommand, error) {
+	p.args = args
+	p.optionPositions = make(map[*Option]int)
+	p.errs = nil
+	p.invalidArguments = make(map[*Argument]bool)
+
 	// Validate command structure first
 	if err := cmd.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid command structure: %v", err)
+		return nil, p.parseError(cmd, fmt.Errorf("invalid command structure: %w", err), -1)
 	}
 
+	p.configPath = ""
+	p.configValues = nil
+	p.results = nil
+
+	result, err := p.parseCommand(cmd, args, nil)
+	if len(p.errs) > 0 {
+		result = nil
+		// Help and version output take precedence over the collected errors
+		if cmdErr, ok := AsCommanderError(err); !ok || cmdErr.ExitCode != 0 {
+			if err != nil {
+				p.errs = append(p.errs, err)
+			}
+			aggregate := NewAggregateError(p.errs)
+			aggregate.Command = cmd.GetFullName()
+			err = aggregate
+		}
+	}
+
+	if err != nil {
+		p.closeParsedFiles()
+	}
+	return result, err
+}
+
+// parseCommand parses arguments for a command whose structure has already been
+// validated, linking the result to the parent command's result
+func (p *Parser) parseCommand(cmd *Command, args []string, parent *ParsedCommand) (*ParsedCommand, error) {
 	result := &ParsedCommand{
-		Command:   cmd,
-		Options:   make(map[string]any),
-		Arguments: make([]any, 0),
-		Unknown:   make([]string, 0),
+		Command:      cmd,
+		Options:      make(map[string]any),
+		Arguments:    make([]any, 0),
+		Unknown:      make([]string, 0),
+		ValueSources: make(map[string]ValueSource),
+		Parent:       parent,
 	}
+	p.results = append(p.results, result)
 
 	// Initialize options with default values
 	for _, option := range cmd.Options {
 		if option.Default != nil {
-			key := p.getOptionKey(option)
-			result.Options[key] = option.Default
+			result.setOption(p.getOptionKey(option), option.Default, ValueSourceDefault)
 		}
 	}
 
 	// Tokenize the arguments with command context for better parsing
 	tokens := p.Tokenize(args, cmd)
 
-	return p.parseTokens(cmd, tokens, result)
+	return p.parseTokens(cmd, args, tokens, result)
 }
 
 // parseTokens processes tokenized arguments
-func (p *Parser) parseTokens(cmd *Command, tokens []Token, result *ParsedCommand) (*ParsedCommand, error) {
+func (p *Parser) parseTokens(cmd *Command, args []string, tokens []Token, result *ParsedCommand) (*ParsedCommand, error) {
 	argIndex := 0
 	doubleDashSeen := false
 
@@ -205,78 +361,123 @@ func (p *Parser) parseTokens(cmd *Command, tokens []Token, result *ParsedCommand
 			continue
 
 		case TokenArgument:
+			// Implicit or explicit "help [command]" subcommand
+			if !doubleDashSeen && argIndex == 0 {
+				if helpCmd := cmd.GetHelpCommand(); helpCmd != nil && token.Value == helpCmd.Name {
+					return nil, p.dispatchHelpCommand(cmd, args, tokens[i+1:])
+				}
+			}
+
 			// Enhanced subcommand resolution
 			if !doubleDashSeen && argIndex == 0 {
-				if subCmd := p.resolveSubcommand(cmd, token.Value, tokens[i+1:]); subCmd != nil {
-					// Found subcommand, parse remaining tokens with it
-					remainingTokens := tokens[i+1:]
-					remainingArgs := make([]string, 0, len(remainingTokens))
-					for _, t := range remainingTokens {
-						remainingArgs = append(remainingArgs, t.Raw)
+				name := token.Value
+				if p.AllowAbbreviations {
+					expanded, err := p.expandSubcommandPrefix(cmd, name)
+					if err != nil {
+						return nil, p.parseError(cmd, err, p.argPosition(args, token.Index))
 					}
+					name = expanded
+				}
+				if subCmd := p.resolveSubcommand(cmd, name, tokens[i+1:]); subCmd != nil {
+					if subCmd.IsExecutableSubcommand() {
+						if err := p.finalizeOptions(cmd, result); err != nil {
+							return nil, p.finalizeError(cmd, err)
+						}
+						return executableResult(subCmd, args[token.Index+1:], result), nil
+					}
+
+					// Found subcommand, parse the original arguments after its name with it
+					remainingArgs := args[token.Index+1:]
 
 					// Set up parser configuration from parent command
 					p.inheritParentConfiguration(cmd, subCmd)
 
-					// Execute pre-subcommand hook if present
-					if cmd.PreSubcommand != nil {
-						if err := cmd.PreSubcommand(cmd, subCmd); err != nil {
-							return nil, fmt.Errorf("pre-subcommand hook failed: %v", err)
-						}
+					// Settle this command's option values before the subcommand sees
+					// them; the checks wait until global options after it are parsed
+					if err := p.settleOptions(cmd, result); err != nil {
+						return nil, p.finalizeError(cmd, err)
 					}
 
 					// Parse with the subcommand
-					subResult, err := p.ParseCommand(subCmd, remainingArgs)
-					if err != nil {
-						return nil, err
-					}
-
-					// Update result to reflect subcommand execution
-					result.Command = subResult.Command
-					result.Options = subResult.Options
-					result.Arguments = subResult.Arguments
-					result.Unknown = subResult.Unknown
-
-					return result, nil
+					return p.parseCommand(subCmd, remainingArgs, result)
 				}
 			}
 
 			// Check for default subcommand if no arguments match and we have a default
 			if !doubleDashSeen && argIndex == 0 && cmd.GetDefaultSubcommand() != nil {
 				defaultCmd := cmd.GetDefaultSubcommand()
-
-				// Parse all remaining tokens with default command
-				remainingArgs := make([]string, 0, len(tokens)-i)
-				for j := i; j < len(tokens); j++ {
-					remainingArgs = append(remainingArgs, tokens[j].Raw)
+				if defaultCmd.IsExecutableSubcommand() {
+					if err := p.finalizeOptions(cmd, result); err != nil {
+						return nil, p.finalizeError(cmd, err)
+					}
+					return executableResult(defaultCmd, args[token.Index:], result), nil
 				}
 
+				// Parse all remaining arguments with default command
+				remainingArgs := args[token.Index:]
+
 				p.inheritParentConfiguration(cmd, defaultCmd)
 
-				// Execute pre-subcommand hook if present
-				if cmd.PreSubcommand != nil {
-					if err := cmd.PreSubcommand(cmd, defaultCmd); err != nil {
-						return nil, fmt.Errorf("pre-subcommand hook failed: %v", err)
-					}
+				if err := p.settleOptions(cmd, result); err != nil {
+					return nil, p.finalizeError(cmd, err)
 				}
 
-				return p.ParseCommand(defaultCmd, remainingArgs)
+				return p.parseCommand(defaultCmd, remainingArgs, result)
+			}
+
+			// POSIX-style parsing: the first operand ends option processing
+			if p.StopAtFirstPositional && !doubleDashSeen {
+				if err := p.passThrough(cmd, args[token.Index:], &argIndex, result); err != nil {
+					return nil, err
+				}
+				return p.validateAndFinalize(cmd, result)
 			}
 
 			// Handle as regular argument
 			if err := p.handleArgument(cmd, token.Value, &argIndex, result); err != nil {
-				return nil, err
+				if err := p.argumentError(cmd, err, p.argPosition(args, token.Index), &argIndex, result); err != nil {
+					return nil, err
+				}
 			}
 
 		case TokenShortOption, TokenLongOption:
 			if doubleDashSeen {
 				// Treat as argument after --
 				if err := p.handleArgument(cmd, token.Raw, &argIndex, result); err != nil {
-					return nil, err
+					if err := p.argumentError(cmd, err, p.argPosition(args, token.Index), &argIndex, result); err != nil {
+						return nil, err
+					}
 				}
 				continue
 			}
 
+			// Expand an abbreviated long option to its full name
+			if p.AllowAbbreviations && token.Type == TokenLongOption {
+				expanded, err := p.expandOptionPrefix(cmd, token.Value)
+				if err != nil {
+					return nil, p.parseError(cmd, err, p.argPosition(args, token.Index))
+				}
+				tokens[i].Value = expanded
+				token = tokens[i]
+			}
+
+			// Built-in help and version options short-circuit parsing
+			option := p.findOptionWithContext(cmd, token.Value, token.Type)
+			if option != nil && option == cmd.HelpOption {
+				return nil, cmd.helpDisplayed()
+			}
+			if option != nil && option == cmd.VersionOption {
+				return nil, cmd.versionDisplayed()
+			}
+
+			// The first unknown option ends option processing when requested
+			if option == nil && p.StopAtFirstUnknown {
+				if err := p.passThrough(cmd, args[token.Index:], &argIndex, result); err != nil {
+					return nil, err
+				}
+				return p.validateAndFinalize(cmd, result)
+			}
+
 			// Handle option
 			consumed, err := p.handleOption(cmd, tokens, i, result)
 			if err != nil {
@@ -284,7 +485,21 @@ func (p *Parser) parseTokens(cmd *Command, tokens []Token, result *ParsedCommand
 					result.Unknown = append(result.Unknown, token.Raw)
 					continue
 				}
-				return nil, err
+				located := p.parseError(cmd, err, p.argPosition(args, p.optionErrorIndex(tokens, i, err)))
+				// An invalid value is skipped when collecting errors
+				if consumed == 0 || !p.collect(located) {
+					return nil, located
+				}
+				i += consumed - 1
+				continue
+			}
+			if option != nil {
+				key := p.getOptionKey(option)
+				owner := result.optionResult(option)
+				if value, exists := owner.Options[key]; exists {
+					owner.setOption(key, value, ValueSourceCLI)
+				}
+				p.optionPositions[option] = p.argPosition(args, token.Index)
 			}
 			i += consumed - 1 // -1 because loop will increment
 
@@ -301,6 +516,204 @@ func (p *Parser) parseTokens(cmd *Command, tokens []Token, result *ParsedCommand
 	return p.validateAndFinalize(cmd, result)
 }
 
+// passThrough assigns the unparsed arguments left after option processing
+// stopped to the remaining declared arguments, keeping the rest verbatim in
+// PassThroughArgs
+func (p *Parser) passThrough(cmd *Command, args []string, argIndex *int, result *ParsedCommand) error {
+	for i, arg := range args {
+		if *argIndex >= len(cmd.Arguments) {
+			result.PassThroughArgs = append(result.PassThroughArgs, args[i:]...)
+			return nil
+		}
+		if err := p.handleArgument(cmd, arg, argIndex, result); err != nil {
+			if err := p.argumentError(cmd, err, p.argPosition(args, i), argIndex, result); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// argPosition converts an index into args, which is always a suffix of the
+// arguments being parsed, into an index into all of them
+func (p *Parser) argPosition(args []string, index int) int {
+	return len(p.args) - len(args) + index
+}
+
+// parseError wraps err in a ParseError locating it at position, an index into
+// the arguments being parsed. Errors already located, and the help and
+// version displays, are returned unchanged.
+func (p *Parser) parseError(cmd *Command, err error, position int) error {
+	var located *ParseError
+	if errors.As(err, &located) {
+		return err
+	}
+
+	parseErr := &ParseError{
+		Command:  cmd.GetFullName(),
+		Message:  err.Error(),
+		Code:     "commander.error",
+		Position: position,
+		Args:     p.args,
+		Err:      err,
+	}
+	if cmdErr, ok := AsCommanderError(err); ok {
+		if cmdErr.ExitCode == 0 || cmdErr.Code == "commander.help" {
+			return err
+		}
+		if cmdErr.Command == "" {
+			cmdErr.Command = parseErr.Command
+		}
+		parseErr.Command = cmdErr.Command
+		parseErr.Code = cmdErr.Code
+	}
+	if position >= 0 && position < len(p.args) {
+		parseErr.Token = p.args[position]
+	}
+
+	switch e := err.(type) {
+	case *UnknownOptionError:
+		parseErr.Option = e.Option
+	case *MissingOptionError:
+		parseErr.Option = e.Option
+	case *OptionMissingArgumentError:
+		parseErr.Option = e.Option
+	case *InvalidOptionArgumentError:
+		parseErr.Option, parseErr.Value = e.Option, e.Value
+	case *MissingArgumentError:
+		parseErr.Argument = e.Argument
+	case *InvalidArgumentError:
+		parseErr.Argument, parseErr.Value = e.Argument, e.Value
+	}
+	return parseErr
+}
+
+// finalizeError locates an error found once the arguments were consumed: a
+// conflict at the last given of the options involved, and a missing option or
+// argument at the end of the command line
+func (p *Parser) finalizeError(cmd *Command, err error) error {
+	var names []string
+	switch e := err.(type) {
+	case *ConflictingOptionError:
+		names = []string{e.Option1, e.Option2}
+	case *OptionGroupError:
+		names = e.Options
+	}
+
+	position := -1
+	for option, at := range p.optionPositions {
+		named := slices.Contains(names, option.displayName(false)) || slices.Contains(names, option.displayName(true))
+		if named && slices.Contains(cmd.Options, option) {
+			position = max(position, at)
+		}
+	}
+	if cmdErr, ok := AsCommanderError(err); ok && position < 0 {
+		switch cmdErr.Code {
+		case "commander.missingOption", "commander.missingArgument":
+			position = len(p.args)
+		}
+	}
+	return p.parseError(cmd, err, position)
+}
+
+// collect records err when CollectErrors is set and err is one of the
+// validation errors collected, reporting whether parsing may carry on
+func (p *Parser) collect(err error) bool {
+	if !p.CollectErrors {
+		return false
+	}
+	cmdErr, ok := AsCommanderError(err)
+	if !ok {
+		return false
+	}
+	switch cmdErr.Code {
+	case "commander.missingOption", "commander.missingArgument", "commander.conflictingOption",
+		"commander.invalidArgument", "commander.invalidOptionArgument":
+		p.errs = append(p.errs, err)
+		return true
+	}
+	return false
+}
+
+// report locates an error found once the arguments were consumed and returns
+// it, or returns nil when it was collected
+func (p *Parser) report(cmd *Command, err error) error {
+	located := p.finalizeError(cmd, err)
+	if p.collect(located) {
+		return nil
+	}
+	return located
+}
+
+// argumentError locates an error handling the argument at position. When the
+// error is collected it returns nil and moves on to the next declared
+// argument, leaving the invalid one unset.
+func (p *Parser) argumentError(cmd *Command, err error, position int, argIndex *int, result *ParsedCommand) error {
+	located := p.parseError(cmd, err, position)
+	var invalid *InvalidArgumentError
+	if !errors.As(err, &invalid) || *argIndex >= len(cmd.Arguments) || !p.collect(located) {
+		return located
+	}
+
+	arg := cmd.Arguments[*argIndex]
+	p.invalidArguments[arg] = true
+	if !arg.Variadic {
+		for len(result.Arguments) <= *argIndex {
+			result.Arguments = append(result.Arguments, nil)
+		}
+		*argIndex++
+	}
+	return nil
+}
+
+// optionErrorIndex returns the index of the argument to blame for an error
+// handling the option token at index: the offending value when the value was
+// invalid, otherwise the option itself
+func (p *Parser) optionErrorIndex(tokens []Token, index int, err error) int {
+	var invalid *InvalidOptionArgumentError
+	if errors.As(err, &invalid) && invalid.Value != "" {
+		for _, token := range tokens[index+1:] {
+			if token.Type != TokenOptionValue && token.Type != TokenArgument {
+				break
+			}
+			if token.Value == invalid.Value {
+				return token.Index
+			}
+		}
+	}
+	return tokens[index].Index
+}
+
+// executableResult records an executable subcommand, which parses its own
+// arguments, with the original command-line arguments it is to receive
+func executableResult(cmd *Command, args []string, parent *ParsedCommand) *ParsedCommand {
+	return &ParsedCommand{
+		Command:      cmd,
+		Options:      make(map[string]any),
+		Arguments:    make([]any, 0),
+		Unknown:      append([]string(nil), args...),
+		ValueSources: make(map[string]ValueSource),
+		Parent:       parent,
+	}
+}
+
+// dispatchHelpCommand displays help for the command named by the operands
+// following "help", walking nested subcommands
+func (p *Parser) dispatchHelpCommand(cmd *Command, args []string, tokens []Token) error {
+	target := cmd
+	for _, token := range tokens {
+		if token.Type != TokenArgument {
+			break
+		}
+		sub := target.FindSubcommandByNameOrAlias(token.Value)
+		if sub == nil {
+			return p.parseError(target, target.unknownCommandError(token.Value), p.argPosition(args, token.Index))
+		}
+		target = sub
+	}
+	return target.helpDisplayed()
+}
+
 // handleArgument processes a regular argument with enhanced validation
 func (p *Parser) handleArgument(cmd *Command, value string, argIndex *int, result *ParsedCommand) error {
 	// Check for positional options first
@@ -312,9 +725,11 @@ func (p *Parser) handleArgument(cmd *Command, value string, argIndex *int, resul
 				key := p.getOptionKey(option)
 				parsedValue, err := option.ProcessOptionValue(value, result.Options[key], false)
 				if err != nil {
-					return fmt.Errorf("invalid positional option value '%s' for option '%s': %v", value, optionName, err)
+					return NewInvalidOptionArgumentError(
+						fmt.Sprintf("invalid positional option value '%s' for option '%s': %v", value, optionName, err),
+						option.displayName(false), value)
 				}
-				result.Options[key] = parsedValue
+				result.setOption(key, parsedValue, ValueSourceCLI)
 				*argIndex++
 				return nil
 			}
@@ -325,7 +740,7 @@ func (p *Parser) handleArgument(cmd *Command, value string, argIndex *int, resul
 		cmdArg := cmd.Arguments[*argIndex]
 
 		// Enhanced validation before parsing
-		if err := p.validateArgumentValue(cmdArg, value); err != nil {
+		if err := p.validateArgumentValue(cmd, cmdArg, value); err != nil {
 			return err
 		}
 
@@ -338,7 +753,9 @@ func (p *Parser) handleArgument(cmd *Command, value string, argIndex *int, resul
 
 			parsedValue, err := cmdArg.ParseValue(value, currentValue)
 			if err != nil {
-				return fmt.Errorf("invalid argument '%s' for parameter '%s': %v", value, cmdArg.Name, err)
+				return NewInvalidArgumentError(
+					fmt.Sprintf("invalid argument '%s' for parameter '%s': %v", value, cmdArg.Name, err),
+					cmdArg.Name, value)
 			}
 
 			// Update or append the variadic argument
@@ -355,7 +772,9 @@ func (p *Parser) handleArgument(cmd *Command, value string, argIndex *int, resul
 			// Regular argument
 			parsedValue, err := cmdArg.ParseValue(value, nil)
 			if err != nil {
-				return fmt.Errorf("invalid argument '%s' for parameter '%s': %v", value, cmdArg.Name, err)
+				return NewInvalidArgumentError(
+					fmt.Sprintf("invalid argument '%s' for parameter '%s': %v", value, cmdArg.Name, err),
+					cmdArg.Name, value)
 			}
 			result.Arguments = append(result.Arguments, parsedValue)
 			*argIndex++
@@ -371,8 +790,10 @@ func (p *Parser) handleArgument(cmd *Command, value string, argIndex *int, resul
 		} else if cmd.AllowExcessArguments {
 			result.Unknown = append(result.Unknown, value)
 		} else {
-			return fmt.Errorf("unexpected argument: %s (expected %d arguments, got %d)",
+			err := NewExcessArgumentsError(len(cmd.Arguments), *argIndex+1)
+			err.Message = fmt.Sprintf("unexpected argument: %s (expected %d arguments, got %d)",
 				value, len(cmd.Arguments), *argIndex+1)
+			return err
 		}
 	}
 
@@ -380,10 +801,10 @@ func (p *Parser) handleArgument(cmd *Command, value string, argIndex *int, resul
 }
 
 // validateArgumentValue performs pre-parsing validation on argument values
-func (p *Parser) validateArgumentValue(arg *Argument, value string) error {
+func (p *Parser) validateArgumentValue(cmd *Command, arg *Argument, value string) error {
 	// Check for empty values on required arguments
 	if arg.Required && strings.TrimSpace(value) == "" {
-		return fmt.Errorf("argument '%s' cannot be empty", arg.Name)
+		return NewInvalidArgumentError(fmt.Sprintf("argument '%s' cannot be empty", arg.Name), arg.Name, value)
 	}
 
 	// Validate against choices if specified
@@ -396,8 +817,14 @@ func (p *Parser) validateArgumentValue(arg *Argument, value string) error {
 			}
 		}
 		if !found {
-			return fmt.Errorf("invalid choice '%s' for argument '%s', expected one of: %s",
+			message := fmt.Sprintf("invalid choice '%s' for argument '%s', expected one of: %s",
 				value, arg.Name, strings.Join(arg.Choices, ", "))
+			if cmd.ShowSuggestionAfterError {
+				if suggestion := suggestChoice(value, arg.Choices); suggestion != "" {
+					message += "\n" + suggestion
+				}
+			}
+			return NewInvalidArgumentError(message, arg.Name, value)
 		}
 	}
 
@@ -495,25 +922,15 @@ func (p *Parser) isKnownOption(cmd *Command, flag string) bool {
 	return false
 }
 
-// findSimilarOption finds a similar option name using simple string matching
+// findSimilarOption finds the option closest to flag by edit distance
 func (p *Parser) findSimilarOption(cmd *Command, flag string) string {
-	cleanFlag := strings.TrimLeft(flag, "-")
-
-	// Simple similarity check - look for options that start with the same letter
-	// or have similar length and characters
+	var candidates []string
 	for _, option := range cmd.Options {
-		if option.Long != "" {
-			if strings.HasPrefix(option.Long, cleanFlag[:1]) && len(option.Long) >= len(cleanFlag)-1 {
-				return "--" + option.Long
-			}
-		}
-		if option.Short != "" {
-			if option.Short == cleanFlag[:1] {
-				return "-" + option.Short
-			}
-		}
+		candidates = append(candidates, option.longFlags()...)
+	}
+	if similar := suggestSimilar("--"+strings.TrimLeft(flag, "-"), candidates); len(similar) > 0 {
+		return similar[0]
 	}
-
 	return ""
 }
 
@@ -562,6 +979,18 @@ func (p *Parser) inheritParentConfiguration(parent, child *Command) {
 	if parent.EnablePositionalOptions {
 		p.EnablePositionalOptions = true
 	}
+	if parent.AllowAbbreviations {
+		p.AllowAbbreviations = true
+	}
+	if parent.StopAtFirstPositional {
+		p.StopAtFirstPositional = true
+	}
+	if parent.StopAtFirstUnknown {
+		p.StopAtFirstUnknown = true
+	}
+	if parent.CollectErrors {
+		p.CollectErrors = true
+	}
 }
 
 // validateAndFinalize performs final validation and cleanup with enhanced argument validation
@@ -569,30 +998,248 @@ func (p *Parser) validateAndFinalize(cmd *Command, result *ParsedCommand) (*Pars
 	// Enhanced argument validation using ArgumentProcessor
 	if len(cmd.Arguments) > 0 {
 		if err := p.validateArgumentsEnhanced(cmd, result); err != nil {
-			return nil, err
+			return nil, p.finalizeError(cmd, err)
 		}
 	}
 
-	// Validate required options
+	if err := p.finalizeOptions(cmd, result); err != nil {
+		return nil, p.finalizeError(cmd, err)
+	}
+	if err := p.mergeGlobalOptions(result); err != nil {
+		return nil, p.finalizeError(cmd, err)
+	}
+
+	// Enhanced validation for nested commands
+	if err := p.validateCommandHierarchy(cmd, result); err != nil {
+		return nil, p.finalizeError(cmd, err)
+	}
+
+	return result, nil
+}
+
+// finalizeOptions settles the option values of the command, then checks the
+// options of the result and of its ancestors, whose checks wait until the
+// leaf is parsed so global options given after a subcommand take part
+func (p *Parser) finalizeOptions(cmd *Command, result *ParsedCommand) error {
+	if err := p.settleOptions(cmd, result); err != nil {
+		return err
+	}
+
+	var chain []*ParsedCommand
+	for r := result; r != nil; r = r.Parent {
+		chain = append([]*ParsedCommand{r}, chain...)
+	}
+	for _, r := range chain {
+		if err := p.checkOptions(r.Command, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settleOptions layers config and environment values under the command-line
+// values (cli > env > config > default)
+func (p *Parser) settleOptions(cmd *Command, result *ParsedCommand) error {
+	// Env only fills unset, default or config values and config only fills
+	// unset or default ones, so env goes first to let it name the config file
+	if err := p.applyEnvOptions(cmd, result); err != nil {
+		return err
+	}
+	return p.applyConfigOptions(cmd, result)
+}
+
+// checkOptions applies implied values, then checks conflicts, option groups
+// and required options
+func (p *Parser) checkOptions(cmd *Command, result *ParsedCommand) error {
+	p.applyImpliedOptions(cmd, result)
+	if err := p.checkConflictingOptions(cmd, result); err != nil {
+		return err
+	}
+	for _, group := range cmd.OptionGroups {
+		err := group.validateSet(func(option *Option) (any, bool) {
+			key := p.getOptionKey(option)
+			return result.Options[key], result.isUserSet(key)
+		})
+		if err != nil {
+			err.Command = cmd.GetFullName()
+			if err := p.report(cmd, err); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Validate required options; global ones may still be given after a
+	// subcommand, so mergeGlobalOptions checks them once parsing is done
 	for _, option := range cmd.Options {
-		if option.Required {
+		if option.Required && !option.Global {
 			key := p.getOptionKey(option)
 			if _, exists := result.Options[key]; !exists {
-				return nil, fmt.Errorf("missing required option: %s", option.Flags)
+				if err := p.report(cmd, NewMissingOptionError(option.Flags)); err != nil {
+					return err
+				}
 			}
 		}
 	}
 
-	// Enhanced validation for nested commands
-	if err := p.validateCommandHierarchy(cmd, result); err != nil {
-		return nil, err
+	return nil
+}
+
+// mergeGlobalOptions copies the global option values of the ancestors into
+// the leaf result, where a value the leaf already has wins unless it is a
+// default and the ancestor's value was set by the user. It then reports
+// required global options that were never given.
+func (p *Parser) mergeGlobalOptions(result *ParsedCommand) error {
+	for r := result; r != nil; r = r.Parent {
+		for _, option := range r.Command.Options {
+			if !option.Global {
+				continue
+			}
+			key := p.getOptionKey(option)
+			value, exists := r.Options[key]
+			if !exists {
+				if option.Required {
+					if err := p.report(result.Command, NewMissingOptionError(option.Flags)); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if r == result {
+				continue
+			}
+			if _, taken := result.Options[key]; taken && (result.isUserSet(key) || !r.isUserSet(key)) {
+				continue
+			}
+			result.setOption(key, value, r.ValueSources[key])
+		}
 	}
+	return nil
+}
 
-	return result, nil
+// applyEnvOptions fills options from their environment variables when they were
+// not set on the command line. Boolean options treat "0", "false", "no" and "off"
+// as false; for options declared as --no-xxx the env value sets the negation.
+func (p *Parser) applyEnvOptions(cmd *Command, result *ParsedCommand) error {
+	for _, option := range cmd.Options {
+		if option.Env == "" {
+			continue
+		}
+		envValue, ok := os.LookupEnv(option.Env)
+		if !ok {
+			continue
+		}
+
+		key := p.getOptionKey(option)
+		switch result.GetOptionValueSource(key) {
+		case "", ValueSourceDefault, ValueSourceConfig, ValueSourceEnv:
+		default:
+			continue
+		}
+
+		if option.Type == OptionTypeBoolean {
+			enabled := true
+			if parsed, err := DefaultBoolParser(envValue, nil); err == nil {
+				enabled = parsed.(bool)
+			}
+			if option.Negatable && strings.Contains(option.Flags, "--no-") {
+				enabled = !enabled
+			}
+			result.setOption(key, enabled, ValueSourceEnv)
+			continue
+		}
+
+		value, err := option.ProcessOptionValue(envValue, nil, false)
+		if err != nil {
+			return NewInvalidOptionArgumentError(
+				fmt.Sprintf("invalid value '%s' for option %s from environment variable %s: %v",
+					envValue, option.Flags, option.Env, err),
+				option.displayName(false), envValue)
+		}
+		result.setOption(key, value, ValueSourceEnv)
+	}
+
+	return nil
+}
+
+// applyImpliedOptions sets the values implied by options the user set, without
+// overriding values the user set themselves (Commander.js implies semantics)
+func (p *Parser) applyImpliedOptions(cmd *Command, result *ParsedCommand) {
+	for _, option := range cmd.Options {
+		implied := option.GetImpliedValues()
+		if len(implied) == 0 {
+			continue
+		}
+
+		key := p.getOptionKey(option)
+		if !result.isUserSet(key) || result.ValueSources[key] == ValueSourceImplied {
+			continue
+		}
+		if value := result.Options[key]; value == nil || value == false {
+			continue
+		}
+
+		for name, value := range implied {
+			impliedKey := strings.TrimLeft(name, "-")
+			if target := cmd.FindOption(impliedKey); target != nil {
+				impliedKey = p.getOptionKey(target)
+			}
+			if result.isUserSet(impliedKey) {
+				continue
+			}
+			result.setOption(impliedKey, value, ValueSourceImplied)
+		}
+	}
+}
+
+// checkConflictingOptions returns a ConflictingOptionError when two options that
+// conflict both have values that did not come from their defaults
+func (p *Parser) checkConflictingOptions(cmd *Command, result *ParsedCommand) error {
+	reported := make(map[[2]*Option]bool)
+	for _, option := range cmd.Options {
+		if len(option.Conflicts) == 0 || !result.isUserSet(p.getOptionKey(option)) {
+			continue
+		}
+
+		for _, conflict := range option.Conflicts {
+			other := cmd.FindOption(strings.TrimLeft(conflict, "-"))
+			if other == nil || other == option {
+				continue
+			}
+			if result.isUserSet(p.getOptionKey(other)) && !reported[[2]*Option{other, option}] {
+				reported[[2]*Option{option, other}] = true
+				name := option.displayName(result.Options[p.getOptionKey(option)] == false)
+				otherName := other.displayName(result.Options[p.getOptionKey(other)] == false)
+				if err := p.report(cmd, NewConflictingOptionError(name, otherName)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
 }
 
 // validateArgumentsEnhanced performs enhanced argument validation using ArgumentProcessor
 func (p *Parser) validateArgumentsEnhanced(cmd *Command, result *ParsedCommand) error {
+	// Report every missing required argument, except those already reported
+	// as invalid when collecting errors
+	complete := true
+	for i, arg := range cmd.Arguments {
+		if !arg.Required || (i < len(result.Arguments) && result.Arguments[i] != nil) {
+			continue
+		}
+		complete = false
+		if p.invalidArguments[arg] {
+			continue
+		}
+		if err := p.report(cmd, NewMissingArgumentError(arg.Name)); err != nil {
+			return err
+		}
+	}
+	if !complete {
+		return nil
+	}
+
 	// Create an ArgumentProcessor with the command's arguments
 	processor := NewArgumentProcessor(cmd.Arguments)
 
@@ -600,9 +1247,15 @@ func (p *Parser) validateArgumentsEnhanced(cmd *Command, result *ParsedCommand)
 	processor.values = make([]any, len(result.Arguments))
 	copy(processor.values, result.Arguments)
 
-	// Use the enhanced validation logic
-	if err := processor.ValidateArguments(); err != nil {
-		return fmt.Errorf("argument validation failed: %v", err)
+	// Use the enhanced validation logic, one argument at a time so errors
+	// name the argument and value. The argument order was checked by
+	// Command.Validate before parsing.
+	processor.FillDefaults()
+	for i, arg := range cmd.Arguments {
+		value, _ := processor.GetValue(i)
+		if err := processor.validateSingleArgument(arg, value, i); err != nil {
+			return NewInvalidArgumentError(fmt.Sprintf("argument validation failed: %v", err), arg.Name, fmt.Sprint(value))
+		}
 	}
 
 	// Update the result with any default values that were filled
@@ -622,6 +1275,11 @@ func (p *Parser) validateCommandHierarchy(cmd *Command, result *ParsedCommand) e
 		}
 
 		for _, opt := range cmd.Options {
+			// Every command carries its own help and version options, so sharing them is expected
+			if opt == cmd.HelpOption || opt == cmd.VersionOption {
+				continue
+			}
+
 			key := p.getOptionKey(opt)
 			if parentOpt, exists := parentOptions[key]; exists {
 				// Check for conflicts
@@ -635,7 +1293,9 @@ func (p *Parser) validateCommandHierarchy(cmd *Command, result *ParsedCommand) e
 	return nil
 }
 
-// handleOption processes an option token and its values with enhanced parsing
+// handleOption processes an option token and its values with enhanced parsing.
+// When a value is invalid, the tokens the option took are still counted so
+// that parsing can carry on after them.
 func (p *Parser) handleOption(cmd *Command, tokens []Token, index int, result *ParsedCommand) (int, error) {
 	token := tokens[index]
 
@@ -687,18 +1347,47 @@ func (p *Parser) handleOption(cmd *Command, tokens []Token, index int, result *P
 			return 1, nil
 		}
 
-		return 0, fmt.Errorf("unknown option: %s", token.Raw)
+		err := NewUnknownOptionError(token.Raw)
+		if cmd.ShowSuggestionAfterError {
+			err.WithSuggestion(cmd.suggestOption(token.Raw, p.EnablePositionalOptions))
+		}
+		err.Command = cmd.GetFullName()
+		return 0, err
 	}
 
+	// Global options are stored with the command declaring them
+	result = result.optionResult(option)
+
 	key := p.getOptionKey(option)
 	isNegated := option.IsNegated(token.Value)
 
+	if option.Accumulation == AccumulateErrorOnRepeat && result.ValueSources[key] == ValueSourceCLI {
+		return 0, NewOptionRepeatedError(option.Flags)
+	}
+
 	// Handle negated boolean options with enhanced logic
 	if option.Negatable && isNegated {
 		result.Options[key] = false
 		return 1, nil
 	}
 
+	// Count options add one per use unless given an explicit count
+	if option.Type == OptionTypeCount {
+		if index+1 < len(tokens) && tokens[index+1].Type == TokenOptionValue {
+			count, err := option.ProcessOptionValue(tokens[index+1].Value, nil, false)
+			if err != nil {
+				return 2, NewInvalidOptionArgumentError(
+					fmt.Sprintf("invalid value '%s' for option %s: %v", tokens[index+1].Value, token.Raw, err),
+					token.Raw, tokens[index+1].Value)
+			}
+			result.Options[key] = count
+			return 2, nil
+		}
+		count, _ := p.previousOptionValue(option, key, result).(int)
+		result.Options[key] = count + 1
+		return 1, nil
+	}
+
 	// Handle boolean options (no value expected)
 	if option.Type == OptionTypeBoolean {
 		// Check for explicit boolean values
@@ -712,7 +1401,8 @@ func (p *Parser) handleOption(cmd *Command, tokens []Token, index int, result *P
 		// Use ProcessOptionValue for consistent handling
 		processedValue, err := option.ProcessOptionValue("", result.Options[key], isNegated)
 		if err != nil {
-			return 0, fmt.Errorf("error processing boolean option %s: %v", token.Raw, err)
+			return 0, NewInvalidOptionArgumentError(
+				fmt.Sprintf("error processing boolean option %s: %v", token.Raw, err), token.Raw, "")
 		}
 		result.Options[key] = processedValue
 		return 1, nil
@@ -727,17 +1417,34 @@ func (p *Parser) handleOption(cmd *Command, tokens []Token, index int, result *P
 
 	// Validate that required options have values
 	if len(values) == 0 && !option.Optional && option.Type != OptionTypeBoolean {
-		return 0, fmt.Errorf("option %s requires a value", token.Raw)
+		return 0, NewOptionMissingArgumentError(option.Flags)
 	}
 
 	// Process collected values using enhanced processing
 	if err := p.processOptionValuesEnhanced(option, values, key, result, token.Raw, isNegated); err != nil {
-		return 0, err
+		return consumed, err
 	}
 
 	return consumed, nil
 }
 
+// previousOptionValue returns the value handed to the option's parser as
+// previous. Before the option is first used on the command line this is its
+// default, except that accumulated lists start afresh, as in Commander.js,
+// and ResetDefault discards any default.
+func (p *Parser) previousOptionValue(option *Option, key string, result *ParsedCommand) any {
+	value := result.Options[key]
+	if result.ValueSources[key] != ValueSourceDefault {
+		return value
+	}
+
+	accumulatesList := option.Parser == nil && (option.Variadic || option.Accumulation == AccumulateAppend)
+	if option.ResetDefault || accumulatesList {
+		return nil
+	}
+	return value
+}
+
 // findOptionWithContext finds an option with enhanced context-aware matching
 func (p *Parser) findOptionWithContext(cmd *Command, flag string, tokenType TokenType) *Option {
 	// Direct match first
@@ -747,6 +1454,11 @@ func (p *Parser) findOptionWithContext(cmd *Command, flag string, tokenType Toke
 		}
 	}
 
+	// Then global options of the ancestors
+	if opt := cmd.findGlobalOption(flag); opt != nil {
+		return opt
+	}
+
 	// For short options, check if it's a negated long option
 	if tokenType == TokenShortOption && len(flag) == 1 {
 		for _, opt := range cmd.Options {
@@ -817,7 +1529,8 @@ func (p *Parser) processOptionValuesEnhanced(option *Option, values []string, ke
 			} else if option.Type == OptionTypeBoolean {
 				processedValue, err := option.ProcessOptionValue("", result.Options[key], isNegated)
 				if err != nil {
-					return fmt.Errorf("error processing option %s: %v", tokenRaw, err)
+					return NewInvalidOptionArgumentError(
+						fmt.Sprintf("error processing option %s: %v", tokenRaw, err), tokenRaw, "")
 				}
 				result.Options[key] = processedValue
 			}
@@ -825,32 +1538,63 @@ func (p *Parser) processOptionValuesEnhanced(option *Option, values []string, ke
 		return nil
 	}
 
+	// Apply the accumulation policy for repeated uses of the option
+	switch option.Accumulation {
+	case AccumulateFirstWins:
+		if result.ValueSources[key] == ValueSourceCLI {
+			return nil
+		}
+	case AccumulateAppend:
+		items, _ := p.previousOptionValue(option, key, result).([]any)
+		for _, value := range values {
+			parsed, err := option.ProcessOptionValue(value, nil, isNegated)
+			if err != nil {
+				return NewInvalidOptionArgumentError(
+					fmt.Sprintf("invalid value '%s' for option %s: %v", value, tokenRaw, err), tokenRaw, value)
+			}
+			if option.Variadic {
+				if list, ok := parsed.([]any); ok {
+					items = append(items, list...)
+					continue
+				}
+			}
+			items = append(items, parsed)
+		}
+		result.Options[key] = items
+		return nil
+	}
+
+	previous := p.previousOptionValue(option, key, result)
+	if option.Accumulation == AccumulateLastWins {
+		previous = nil
+	}
+
 	if option.Variadic {
 		// Parse all values for variadic option
-		var variadicValue any
-		if existing, exists := result.Options[key]; exists {
-			variadicValue = existing
-		}
+		variadicValue := previous
 
 		for _, value := range values {
 			parsed, err := option.ProcessOptionValue(value, variadicValue, isNegated)
 			if err != nil {
-				return fmt.Errorf("invalid value '%s' for option %s: %v", value, tokenRaw, err)
+				return NewInvalidOptionArgumentError(
+					fmt.Sprintf("invalid value '%s' for option %s: %v", value, tokenRaw, err), tokenRaw, value)
 			}
 			variadicValue = parsed
 		}
 		result.Options[key] = variadicValue
 	} else {
 		// Parse single value (use first value if multiple provided)
-		parsed, err := option.ProcessOptionValue(values[0], result.Options[key], isNegated)
+		parsed, err := option.ProcessOptionValue(values[0], previous, isNegated)
 		if err != nil {
-			return fmt.Errorf("invalid value '%s' for option %s: %v", values[0], tokenRaw, err)
+			return NewInvalidOptionArgumentError(
+				fmt.Sprintf("invalid value '%s' for option %s: %v", values[0], tokenRaw, err), tokenRaw, values[0])
 		}
 		result.Options[key] = parsed
 
 		// Warn about extra values for non-variadic options
 		if len(values) > 1 && !p.AllowUnknownOptions {
-			return fmt.Errorf("option %s does not accept multiple values", tokenRaw)
+			return NewInvalidOptionArgumentError(
+				fmt.Sprintf("option %s does not accept multiple values", tokenRaw), tokenRaw, values[1])
 		}
 	}
 
@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
 )
 
@@ -285,3 +289,729 @@ func TestEnhancedArgumentValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestConflictingOptions(t *testing.T) {
+	newCmd := func() *Command {
+		cmd := NewCommand("deploy")
+		cmd.AddOption(NewBooleanOption("--dry-run", "preview only").SetConflicts([]string{"force"}))
+		cmd.AddOption(NewBooleanOption("--force", "skip checks"))
+		cmd.AddOption(NewOption("--mode <mode>", "deploy mode").SetDefault("safe").SetConflicts([]string{"dry-run"}))
+		return cmd
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		expectError bool
+	}{
+		{"both set on command line", []string{"--dry-run", "--force"}, true},
+		{"only one set", []string{"--force"}, false},
+		{"conflict only with a default", []string{"--dry-run"}, false},
+		{"conflict with explicit value", []string{"--dry-run", "--mode", "fast"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser().ParseCommand(newCmd(), tt.args)
+			var conflictErr *ConflictingOptionError
+			if tt.expectError && !errors.As(err, &conflictErr) {
+				t.Errorf("Expected ConflictingOptionError, got %v", err)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	// Test that a negatable option is named in the form that was given
+	for _, flag := range []string{"--color", "--no-color"} {
+		cmd := NewCommand("print")
+		cmd.AddOption(NewOption("--no-color", "disable color").SetConflicts([]string{"mono"}))
+		cmd.AddOption(NewBooleanOption("--mono", "monochrome output"))
+
+		_, err := NewParser().ParseCommand(cmd, []string{flag, "--mono"})
+		var conflictErr *ConflictingOptionError
+		if !errors.As(err, &conflictErr) || conflictErr.Option1 != flag {
+			t.Errorf("Expected a conflict naming %s, got %v", flag, err)
+		}
+	}
+}
+
+func TestImpliedOptions(t *testing.T) {
+	newCmd := func() *Command {
+		cmd := NewCommand("serve")
+		cmd.AddOption(NewBooleanOption("--quick", "quick mode").SetImpliedValues(map[string]any{"port": 80, "smoke": true}))
+		cmd.AddOption(NewBooleanOption("--smoke", "smoke test"))
+		cmd.AddOption(CreateNumberOption("--port <n>", "port").SetDefault(8080))
+		return cmd
+	}
+
+	result, err := NewParser().ParseCommand(newCmd(), []string{"--quick"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Options["port"] != 80 || result.Options["smoke"] != true {
+		t.Errorf("Expected implied port=80 smoke=true, got %v", result.Options)
+	}
+
+	result, err = NewParser().ParseCommand(newCmd(), []string{"--quick", "--port", "9000"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Options["port"] != 9000 {
+		t.Errorf("Implied value should not override an explicit one, got %v", result.Options["port"])
+	}
+
+	result, err = NewParser().ParseCommand(newCmd(), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Options["port"] != 8080 {
+		t.Errorf("Implies should not apply when the option is unset, got %v", result.Options["port"])
+	}
+}
+
+func TestEnvOptionFallback(t *testing.T) {
+	newCmd := func() *Command {
+		cmd := NewCommand("serve")
+		cmd.AddOption(CreateNumberOption("--port <n>", "port").SetEnv("TEST_SERVE_PORT").SetDefault(8080))
+		cmd.AddOption(NewBooleanOption("--debug", "debug output").SetEnv("TEST_SERVE_DEBUG"))
+		cmd.AddOption(NewOption("--no-color", "disable color").SetEnv("TEST_SERVE_NO_COLOR"))
+		cmd.AddOption(NewOption("--host <host>", "host").SetDefault("localhost"))
+		return cmd
+	}
+
+	t.Setenv("TEST_SERVE_PORT", "9000")
+	t.Setenv("TEST_SERVE_DEBUG", "no")
+	t.Setenv("TEST_SERVE_NO_COLOR", "1")
+
+	result, err := NewParser().ParseCommand(newCmd(), nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Options["port"] != 9000 || result.GetOptionValueSource("port") != ValueSourceEnv {
+		t.Errorf("Expected port 9000 from env, got %v (%s)", result.Options["port"], result.GetOptionValueSource("port"))
+	}
+	if result.Options["debug"] != false {
+		t.Errorf("Expected debug=false from env value 'no', got %v", result.Options["debug"])
+	}
+	if result.Options["color"] != false {
+		t.Errorf("Expected color=false from negated env, got %v", result.Options["color"])
+	}
+	if result.GetOptionValueSource("host") != ValueSourceDefault {
+		t.Errorf("Expected host source default, got %s", result.GetOptionValueSource("host"))
+	}
+
+	result, err = NewParser().ParseCommand(newCmd(), []string{"--port", "7000"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Options["port"] != 7000 || result.GetOptionValueSource("port") != ValueSourceCLI {
+		t.Errorf("Command line should win over env, got %v (%s)", result.Options["port"], result.GetOptionValueSource("port"))
+	}
+
+	result, err = NewParser().ParseCommand(newCmd(), []string{"--debug"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Options["debug"] != true || result.GetOptionValueSource("debug") != ValueSourceCLI {
+		t.Errorf("Boolean flag should win over a falsy env value, got %v (%s)", result.Options["debug"], result.GetOptionValueSource("debug"))
+	}
+}
+
+func TestCountOptions(t *testing.T) {
+	newCmd := func() *Command {
+		cmd := NewCommand("app")
+		cmd.AddOption(NewCountOption("-v, --verbose", "verbosity").SetEnv("TEST_APP_VERBOSE"))
+		cmd.AddOption(NewBooleanOption("-q, --quiet", "quiet"))
+		cmd.AddOption(NewCountOption("-d, --debug", "debug level").SetDefault(2))
+		return cmd
+	}
+
+	tests := []struct {
+		args    []string
+		verbose int
+		debug   int
+	}{
+		{nil, 0, 2},
+		{[]string{"-v"}, 1, 2},
+		{[]string{"-vvv"}, 3, 2},
+		{[]string{"-vqv", "--verbose"}, 3, 2},
+		{[]string{"--verbose=5", "-v"}, 6, 2},
+		{[]string{"-dd"}, 0, 4},
+	}
+	for _, tt := range tests {
+		result, err := NewParser().ParseCommand(newCmd(), tt.args)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", tt.args, err)
+		}
+		if result.Options["verbose"] != tt.verbose || result.Options["debug"] != tt.debug {
+			t.Errorf("%v: expected verbose=%d debug=%d, got %v %v",
+				tt.args, tt.verbose, tt.debug, result.Options["verbose"], result.Options["debug"])
+		}
+	}
+
+	reset := newCmd()
+	reset.FindOption("debug").SetResetDefault(true)
+	result, err := NewParser().ParseCommand(reset, []string{"-d"})
+	if err != nil || result.Options["debug"] != 1 {
+		t.Errorf("Expected ResetDefault to count from zero, got %v, %v", result.Options["debug"], err)
+	}
+
+	t.Setenv("TEST_APP_VERBOSE", "2")
+	result, err = NewParser().ParseCommand(newCmd(), nil)
+	if err != nil || result.Options["verbose"] != 2 {
+		t.Errorf("Expected verbose=2 from env, got %v, %v", result.Options["verbose"], err)
+	}
+
+	if help := newCmd().GenerateHelp(); strings.Contains(help, "default: 0") || !strings.Contains(help, "(default: 2)") {
+		t.Errorf("Expected only non-zero count defaults in help, got:\n%s", help)
+	}
+}
+
+func TestOptionAccumulation(t *testing.T) {
+	parse := func(option *Option, args ...string) (any, error) {
+		cmd := NewCommand("app")
+		cmd.AddOption(option)
+		result, err := NewParser().ParseCommand(cmd, args)
+		if err != nil {
+			return nil, err
+		}
+		return result.Options[getOptionKey(option)], nil
+	}
+
+	tests := []struct {
+		name     string
+		option   *Option
+		args     []string
+		expected any
+	}{
+		{"default keeps last", NewOption("--name <n>", "name"), []string{"--name", "a", "--name", "b"}, "b"},
+		{"last wins", NewOption("--name <n>", "name").SetAccumulation(AccumulateLastWins), []string{"--name", "a", "--name", "b"}, "b"},
+		{"first wins", NewOption("--name <n>", "name").SetAccumulation(AccumulateFirstWins), []string{"--name", "a", "--name", "b"}, "a"},
+		{"append", NewOption("--name <n>", "name").SetAccumulation(AccumulateAppend), []string{"--name", "a", "--name=b"}, []any{"a", "b"}},
+		{"append numbers", CreateNumberOption("-n, --num <n>", "number").SetAccumulation(AccumulateAppend), []string{"-n", "1", "-n2"}, []any{1, 2}},
+		{"append replaces default", NewOption("--name <n>", "name").SetAccumulation(AccumulateAppend).SetDefault([]any{"x"}), []string{"--name", "a"}, []any{"a"}},
+		{"append keeps unused default", NewOption("--name <n>", "name").SetAccumulation(AccumulateAppend).SetDefault([]any{"x"}), nil, []any{"x"}},
+		{"variadic replaces default", NewVariadicOption("--tag <t...>", "tags").SetDefault([]any{"x"}), []string{"--tag", "a", "b", "--tag", "c"}, []any{"a", "b", "c"}},
+		{"variadic last wins", NewVariadicOption("--tag <t...>", "tags").SetAccumulation(AccumulateLastWins), []string{"--tag", "a", "b", "--tag", "c"}, []any{"c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.option, tt.args...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %#v, got %#v", tt.expected, got)
+			}
+		})
+	}
+
+	var repeatedErr *OptionRepeatedError
+	if _, err := parse(NewOption("--name <n>", "name").SetAccumulation(AccumulateErrorOnRepeat), "--name", "a", "--name", "b"); !errors.As(err, &repeatedErr) || repeatedErr.Code != "commander.optionRepeated" {
+		t.Errorf("Expected OptionRepeatedError on repeated option, got %v", err)
+	}
+	if _, err := parse(NewBooleanOption("--force", "force").SetAccumulation(AccumulateErrorOnRepeat), "--force", "--force"); err == nil {
+		t.Error("Expected error on repeated flag")
+	}
+	if got, err := parse(NewOption("--name <n>", "name").SetAccumulation(AccumulateErrorOnRepeat), "--name", "a"); err != nil || got != "a" {
+		t.Errorf("Expected a single use to be accepted, got %v, %v", got, err)
+	}
+}
+
+func TestCustomParserPrevious(t *testing.T) {
+	sum := func(value string, previous any) (any, error) {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+		total, _ := previous.(int)
+		return total + n, nil
+	}
+
+	cmd := NewCommand("app")
+	cmd.AddOption(NewOption("--add <n>", "add").SetParser(sum).SetDefault(100))
+	cmd.AddOption(NewOption("--fresh <n>", "add from zero").SetParser(sum).SetDefault(100).SetResetDefault(true))
+
+	result, err := NewParser().ParseCommand(cmd, []string{"--add", "1", "--add", "2", "--fresh", "1", "--fresh", "2"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Options["add"] != 103 {
+		t.Errorf("Expected the default as the first previous value, got %v", result.Options["add"])
+	}
+	if result.Options["fresh"] != 3 {
+		t.Errorf("Expected ResetDefault to discard the default, got %v", result.Options["fresh"])
+	}
+}
+
+func TestOptionGroupEnforcement(t *testing.T) {
+	newCmd := func(configure func(*OptionGroup)) *Command {
+		cmd := NewCommand("app")
+		group := NewOptionGroup("output", "").
+			AddOption(NewBooleanOption("--json", "json")).
+			AddOption(NewOption("--format <f>", "format").SetEnv("TEST_GROUP_FORMAT"))
+		configure(group)
+		cmd.AddOptionGroup(group)
+		return cmd
+	}
+	exclusive := func(g *OptionGroup) { g.SetExclusive(true) }
+	required := func(g *OptionGroup) { g.SetRequired(true) }
+	exactlyOne := func(g *OptionGroup) { g.SetExclusive(true).SetRequired(true) }
+	allOrNone := func(g *OptionGroup) { g.SetAllOrNone(true) }
+
+	tests := []struct {
+		name      string
+		configure func(*OptionGroup)
+		args      []string
+		message   string
+	}{
+		{"exclusive one", exclusive, []string{"--json"}, ""},
+		{"exclusive none", exclusive, nil, ""},
+		{"exclusive both", exclusive, []string{"--json", "--format", "x"}, "option '--json' cannot be used with option '--format'"},
+		{"required none", required, nil, "at least one of '--json', '--format' is required (group 'output')"},
+		{"required both", required, []string{"--json", "--format", "x"}, ""},
+		{"exactly one none", exactlyOne, nil, "exactly one of '--json', '--format' is required"},
+		{"exactly one both", exactlyOne, []string{"--format", "x", "--json"}, "cannot be used with"},
+		{"exactly one", exactlyOne, []string{"--format", "x"}, ""},
+		{"all or none partial", allOrNone, []string{"--json"}, "'--json' must be used together with '--format' (group 'output')"},
+		{"all or none all", allOrNone, []string{"--json", "--format", "x"}, ""},
+		{"all or none none", allOrNone, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewParser().ParseCommand(newCmd(tt.configure), tt.args)
+			if tt.message == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+
+			var groupErr *OptionGroupError
+			if !errors.As(err, &groupErr) {
+				t.Fatalf("Expected OptionGroupError, got %v", err)
+			}
+			if !strings.Contains(groupErr.Message, tt.message) || groupErr.Group != "output" || groupErr.Command != "app" {
+				t.Errorf("Unexpected error %+v, expected message containing %q", groupErr, tt.message)
+			}
+		})
+	}
+
+	// Values from the environment count as set
+	t.Setenv("TEST_GROUP_FORMAT", "yaml")
+	if _, err := NewParser().ParseCommand(newCmd(exclusive), []string{"--json"}); err == nil {
+		t.Error("Expected env value to conflict with --json")
+	}
+
+	cmd := NewCommand("app")
+	cmd.OptionGroups = append(cmd.OptionGroups, NewOptionGroup("orphans", "").AddOption(NewBooleanOption("--extra", "extra")))
+	if err := cmd.Validate(); err == nil {
+		t.Error("Expected Validate to reject group options missing from the command")
+	}
+}
+
+func TestGlobalOptions(t *testing.T) {
+	newRoot := func() *Command {
+		root := NewCommand("app")
+		root.AddGlobalOption(NewBooleanOption("-v, --verbose", "verbose output"))
+		root.AddGlobalOption(NewOption("-c, --config <path>", "config file").SetDefault("app.json"))
+		root.AddOption(NewBooleanOption("--local", "root only"))
+
+		remote := NewCommand("remote")
+		remote.AddGlobalOption(NewOption("--region <r>", "region").SetEnv("TEST_GLOBAL_REGION"))
+		root.AddSubcommand(remote)
+
+		add := NewCommand("add")
+		add.AddOption(NewBooleanOption("-f, --force", "force"))
+		add.AddArgument(NewArgument("<name>", "remote name"))
+		remote.AddSubcommand(add)
+
+		shadow := NewCommand("shadow")
+		shadow.AddOption(NewOption("-c, --count <n>", "count"))
+		root.AddSubcommand(shadow)
+		return root
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected map[string]any
+	}{
+		{"before subcommand", []string{"-v", "remote", "add", "origin"},
+			map[string]any{"verbose": true, "config": "app.json", "force": false}},
+		{"after subcommand", []string{"remote", "add", "origin", "--verbose", "--config", "x.json", "--region", "eu"},
+			map[string]any{"verbose": true, "config": "x.json", "region": "eu", "force": false}},
+		{"combined short flags", []string{"remote", "add", "-fv", "origin", "-cy.json"},
+			map[string]any{"verbose": true, "config": "y.json", "force": true}},
+		{"later value wins", []string{"--config", "a.json", "remote", "add", "origin", "--config", "b.json"},
+			map[string]any{"verbose": false, "config": "b.json", "force": false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := NewParser().ParseCommand(newRoot(), tt.args)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Command.Name != "add" || result.Arguments[0] != "origin" {
+				t.Fatalf("Expected add origin, got %s %v", result.Command.Name, result.Arguments)
+			}
+			if !reflect.DeepEqual(result.Options, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result.Options)
+			}
+			// Global values are stored with the command declaring them
+			if result.Parent.Parent.Options["config"] != tt.expected["config"] {
+				t.Errorf("Expected root config %v, got %v", tt.expected["config"], result.Parent.Parent.Options["config"])
+			}
+		})
+	}
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("TEST_GLOBAL_REGION", "us")
+		result, err := NewParser().ParseCommand(newRoot(), []string{"remote", "add", "origin"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Options["region"] != "us" || result.GetOptionValueSource("region") != ValueSourceEnv {
+			t.Errorf("Expected region from env, got %v (%s)", result.Options["region"], result.GetOptionValueSource("region"))
+		}
+	})
+
+	t.Run("local options stay local", func(t *testing.T) {
+		if _, err := NewParser().ParseCommand(newRoot(), []string{"remote", "add", "origin", "--local"}); err == nil {
+			t.Error("Expected --local to be unknown to subcommands")
+		}
+	})
+
+	t.Run("shadowed", func(t *testing.T) {
+		result, err := NewParser().ParseCommand(newRoot(), []string{"shadow", "-c", "3"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Options["count"] != "3" || result.Parent.Options["config"] != "app.json" {
+			t.Errorf("Expected the local -c to shadow the global one, got %v", result.Options)
+		}
+	})
+
+	t.Run("required", func(t *testing.T) {
+		root := newRoot()
+		root.FindOption("config").SetDefault(nil).SetRequired(true)
+		if _, err := NewParser().ParseCommand(root, []string{"remote", "add", "origin", "-c", "z.json"}); err != nil {
+			t.Errorf("Expected a required global given after the subcommand to be accepted, got %v", err)
+		}
+		if _, err := NewParser().ParseCommand(root, []string{"remote", "add", "origin"}); err == nil || !strings.Contains(err.Error(), "--config") {
+			t.Errorf("Expected missing required global option, got %v", err)
+		}
+	})
+
+	newRelationsRoot := func() *Command {
+		root := NewCommand("app")
+		root.AddGlobalOption(NewBooleanOption("--dry-run", "dry run").SetConflicts([]string{"force"}))
+		root.AddGlobalOption(NewBooleanOption("--force", "force"))
+		root.AddGlobalOption(NewBooleanOption("--quick", "quick").SetImpliedValues(map[string]any{"smoke": true}))
+		root.AddGlobalOption(NewBooleanOption("--smoke", "smoke tests only"))
+		json := NewBooleanOption("--json", "write JSON").SetGlobal(true)
+		yaml := NewBooleanOption("--yaml", "write YAML").SetGlobal(true)
+		root.AddOptionGroup(NewOptionGroup("Output", "").SetExclusive(true).AddOption(json).AddOption(yaml))
+		root.AddSubcommand(NewCommand("deploy"))
+		return root
+	}
+
+	for _, position := range []struct {
+		name   string
+		layout func(options ...string) []string
+	}{
+		{"before subcommand", func(options ...string) []string { return append(options, "deploy") }},
+		{"after subcommand", func(options ...string) []string { return append([]string{"deploy"}, options...) }},
+		{"around subcommand", func(options ...string) []string { return []string{options[0], "deploy", options[len(options)-1]} }},
+	} {
+		t.Run("relations "+position.name, func(t *testing.T) {
+			_, err := NewParser().ParseCommand(newRelationsRoot(), position.layout("--dry-run", "--force"))
+			if !errors.As(err, new(*ConflictingOptionError)) {
+				t.Errorf("Expected ConflictingOptionError, got %v", err)
+			}
+
+			_, err = NewParser().ParseCommand(newRelationsRoot(), position.layout("--json", "--yaml"))
+			if !errors.As(err, new(*OptionGroupError)) {
+				t.Errorf("Expected OptionGroupError, got %v", err)
+			}
+
+			result, err := NewParser().ParseCommand(newRelationsRoot(), position.layout("--quick", "--quick"))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Options["smoke"] != true || result.GetOptionValueSource("smoke") != ValueSourceImplied {
+				t.Errorf("Expected implied smoke, got %v (%s)", result.Options["smoke"], result.GetOptionValueSource("smoke"))
+			}
+		})
+	}
+}
+
+func TestOptsWithGlobals(t *testing.T) {
+	root := NewCommand("app")
+	root.AddOption(NewOption("--name <n>", "name").SetDefault("root"))
+	root.AddOption(NewOption("--level <n>", "level").SetDefault("1"))
+	mid := NewCommand("mid")
+	mid.AddOption(NewBooleanOption("--dry-run", "dry run"))
+	root.AddSubcommand(mid)
+	leaf := NewCommand("leaf")
+	leaf.AddOption(NewOption("--name <n>", "name").SetDefault("leaf"))
+	leaf.AddOption(NewOption("--level <n>", "level").SetDefault("2"))
+	mid.AddSubcommand(leaf)
+
+	result, err := NewParser().ParseCommand(root, []string{"--level", "9", "mid", "--dry-run", "leaf"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// The nearest default wins over a farther default, a user value over both
+	expected := map[string]any{"name": "leaf", "level": "9", "dry-run": true}
+	if opts := result.OptsWithGlobals(); !reflect.DeepEqual(opts, expected) {
+		t.Errorf("Expected %v, got %v", expected, opts)
+	}
+}
+
+func TestSubcommandKeepsRawArguments(t *testing.T) {
+	root := NewCommand("app")
+	sub := NewCommand("run")
+	sub.AddOption(NewOption("--name <n>", "name"))
+	sub.AddArgument(NewArgument("[rest...]", "rest"))
+	root.AddSubcommand(sub)
+
+	result, err := NewParser().ParseCommand(root, []string{"run", "--name=value", "x"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Options["name"] != "value" || !reflect.DeepEqual(result.Arguments, []any{[]any{"x"}}) {
+		t.Errorf("Expected --name=value to reach the subcommand once, got %v %v", result.Options, result.Arguments)
+	}
+}
+
+func TestStopAtFirstPositional(t *testing.T) {
+	newRoot := func() *Command {
+		root := NewCommand("mytool")
+		root.AddOption(NewBooleanOption("-v, --verbose", "verbose"))
+		exec := NewCommand("exec")
+		exec.AddOption(NewBooleanOption("-i, --interactive", "interactive"))
+		exec.AddArgument(NewArgument("<program>", "program to run"))
+		root.AddSubcommand(exec)
+		return root
+	}
+
+	// Interspersed by default: options after operands are still parsed
+	root := newRoot()
+	result, err := root.NewParser().ParseCommand(root, []string{"exec", "docker", "-i"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Options["interactive"] != true {
+		t.Errorf("Expected -i to be parsed in interspersed mode, got %v", result.Options)
+	}
+
+	root = newRoot()
+	root.StopAtFirstPositional = true
+	args := []string{"-v", "exec", "-i", "docker", "run", "-it", "--rm", "--name=web", "nginx"}
+	result, err = root.NewParser().ParseCommand(root, args)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Options["interactive"] != true || result.Parent.Options["verbose"] != true {
+		t.Errorf("Expected options before the first operand to be parsed, got %v", result.Options)
+	}
+	if !reflect.DeepEqual(result.Arguments, []any{"docker"}) {
+		t.Errorf("Expected program docker, got %v", result.Arguments)
+	}
+	expected := []string{"run", "-it", "--rm", "--name=web", "nginx"}
+	if !reflect.DeepEqual(result.PassThroughArgs, expected) {
+		t.Errorf("Expected pass-through %v, got %v", expected, result.PassThroughArgs)
+	}
+	if got := actionArguments(result); !reflect.DeepEqual(got, append([]string{"docker"}, expected...)) {
+		t.Errorf("Expected action arguments to end with the pass-through args, got %v", got)
+	}
+}
+
+func TestStopAtFirstUnknown(t *testing.T) {
+	cmd := NewCommand("wrap")
+	cmd.StopAtFirstUnknown = true
+	cmd.AddOption(NewBooleanOption("-q, --quiet", "quiet"))
+
+	result, err := cmd.NewParser().ParseCommand(cmd, []string{"-q", "--color=always", "-q", "file"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Options["quiet"] != true {
+		t.Errorf("Expected -q before the unknown option to be parsed, got %v", result.Options)
+	}
+	if expected := []string{"--color=always", "-q", "file"}; !reflect.DeepEqual(result.PassThroughArgs, expected) {
+		t.Errorf("Expected pass-through %v, got %v", expected, result.PassThroughArgs)
+	}
+}
+
+func TestParseErrorLocation(t *testing.T) {
+	newRoot := func() *Command {
+		root := NewCommand("app")
+		build := NewCommand("build")
+		build.AllowExcessArguments = false
+		build.AddOption(NewOption("-p, --port <n>", "port").SetParser(DefaultIntParser))
+		build.AddOption(NewOption("-o, --output <dir>", "output"))
+		build.AddOption(NewBooleanOption("--fast", "fast").SetConflicts([]string{"safe"}))
+		build.AddOption(NewBooleanOption("--safe", "safe"))
+		build.AddArgument(NewArgument("<target>", "target"))
+		root.AddSubcommand(build)
+		return root
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		code     string
+		position int
+		token    string
+		target   any
+	}{
+		{"unknown option", []string{"build", "--colr", "x"}, "commander.unknownOption", 1, "--colr", new(*UnknownOptionError)},
+		{"invalid value", []string{"build", "x", "-p", "abc"}, "commander.invalidOptionArgument", 3, "abc", new(*InvalidOptionArgumentError)},
+		{"missing value", []string{"build", "x", "-o"}, "commander.optionMissingArgument", 2, "-o", new(*OptionMissingArgumentError)},
+		{"missing argument", []string{"build", "-p", "80"}, "commander.missingArgument", 3, "", new(*MissingArgumentError)},
+		{"excess argument", []string{"build", "x", "y"}, "commander.excessArguments", 2, "y", new(*ExcessArgumentsError)},
+		{"conflict", []string{"build", "--safe", "x", "--fast"}, "commander.conflictingOption", 3, "--fast", new(*ConflictingOptionError)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newRoot()
+			_, err := root.NewParser().ParseCommand(root, tt.args)
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a ParseError, got %T: %v", err, err)
+			}
+			if parseErr.Code != tt.code || parseErr.Position != tt.position || parseErr.Token != tt.token {
+				t.Errorf("Expected %s at %d (%q), got %s at %d (%q)",
+					tt.code, tt.position, tt.token, parseErr.Code, parseErr.Position, parseErr.Token)
+			}
+			if parseErr.Command != "app build" {
+				t.Errorf("Expected command path 'app build', got %q", parseErr.Command)
+			}
+			if !errors.As(err, tt.target) {
+				t.Errorf("Expected errors.As to find %T in %v", tt.target, err)
+			}
+			if cmdErr, ok := AsCommanderError(err); !ok || cmdErr.Code != tt.code {
+				t.Errorf("Expected AsCommanderError to find code %s, got %v", tt.code, cmdErr)
+			}
+		})
+	}
+}
+
+func TestArgumentValidationError(t *testing.T) {
+	cmd := NewCommand("deploy")
+	cmd.AddArgument(NewArgument("[env]", "environment").SetChoices([]string{"dev", "prod"}).SetDefault("qa"))
+
+	_, err := NewParser().ParseCommand(cmd, nil)
+	var argErr *InvalidArgumentError
+	if !errors.As(err, &argErr) || argErr.Argument != "env" || argErr.Value != "qa" {
+		t.Errorf("Expected InvalidArgumentError naming env and qa, got %#v", err)
+	}
+}
+
+func TestParseErrorRender(t *testing.T) {
+	root := NewCommand("app")
+	build := NewCommand("build")
+	build.AddOption(NewOption("-o, --output <dir>", "output"))
+	build.AddArgument(NewArgument("<target>", "target"))
+	root.AddSubcommand(build)
+
+	_, err := root.NewParser().ParseCommand(root, []string{"build", "--colr", "my target"})
+	expected := "unknown option '--colr'\n" +
+		"  app build --colr \"my target\"\n" +
+		"            ^^^^^^"
+	if got := RenderError(err); got != expected {
+		t.Errorf("Expected rendering:\n%s\ngot:\n%s", expected, got)
+	}
+
+	_, err = root.NewParser().ParseCommand(root, []string{"build", "-o", "dist"})
+	expected = "missing required argument 'target'\n" +
+		"  app build -o dist\n" +
+		"                    ^"
+	if got := RenderError(err); got != expected {
+		t.Errorf("Expected rendering at the end of the line:\n%s\ngot:\n%s", expected, got)
+	}
+
+	plain := errors.New("not a parse error")
+	if got := RenderError(plain); got != plain.Error() {
+		t.Errorf("Expected the plain message, got %q", got)
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	newCmd := func(collect bool) *Command {
+		cmd := NewCommand("deploy")
+		cmd.CollectErrors = collect
+		cmd.AddOption(CreateRequiredOption("-r, --region <region>", "region"))
+		cmd.AddOption(CreateRequiredOption("-t, --token <token>", "token"))
+		cmd.AddOption(NewOption("-l, --level <level>", "level").SetChoices([]string{"low", "high"}))
+		cmd.AddOption(NewBooleanOption("--fast", "fast").SetConflicts([]string{"safe"}))
+		cmd.AddOption(NewBooleanOption("--safe", "safe"))
+		cmd.AddArgument(NewArgument("<env>", "environment").SetChoices([]string{"dev", "prod"}))
+		cmd.AddArgument(NewArgument("<service>", "service"))
+		return cmd
+	}
+	args := []string{"--fast", "-l", "max", "qa", "--safe"}
+
+	cmd := newCmd(false)
+	_, err := cmd.NewParser().ParseCommand(cmd, args)
+	var aggregate *AggregateError
+	if err == nil || errors.As(err, &aggregate) {
+		t.Fatalf("Expected only the first error without CollectErrors, got %v", err)
+	}
+
+	cmd = newCmd(true)
+	_, err = cmd.NewParser().ParseCommand(cmd, args)
+	if !errors.As(err, &aggregate) {
+		t.Fatalf("Expected an AggregateError, got %T: %v", err, err)
+	}
+
+	var codes []string
+	for _, e := range aggregate.Errors {
+		var parseErr *ParseError
+		if !errors.As(e, &parseErr) {
+			t.Fatalf("Expected each collected error to be a ParseError, got %T", e)
+		}
+		codes = append(codes, parseErr.Code)
+	}
+	expected := []string{
+		"commander.invalidOptionArgument", // -l max
+		"commander.invalidArgument",       // qa
+		"commander.missingArgument",       // service
+		"commander.conflictingOption",     // --fast and --safe
+		"commander.missingOption",         // --region
+		"commander.missingOption",         // --token
+	}
+	if !reflect.DeepEqual(codes, expected) {
+		t.Errorf("Expected codes %v, got %v", expected, codes)
+	}
+
+	var missing *MissingOptionError
+	if !errors.As(err, &missing) || missing.Option != "-r, --region <region>" {
+		t.Errorf("Expected errors.As to find the first missing option, got %v", missing)
+	}
+	if len(strings.Split(err.Error(), "\n")) != len(expected) {
+		t.Errorf("Expected one line per error, got %q", err.Error())
+	}
+
+	message := errorMessage(err)
+	if !strings.HasPrefix(message, "Error: 6 problems found:\n  - ") ||
+		!strings.Contains(message, "\n  - missing required option '-t, --token <token>'\n") {
+		t.Errorf("Expected the errors listed, got:\n%s", message)
+	}
+
+	// A valid command line still parses
+	cmd = newCmd(true)
+	result, err := cmd.NewParser().ParseCommand(cmd, []string{"-r", "eu", "-t", "x", "dev", "api"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Arguments, []any{"dev", "api"}) {
+		t.Errorf("Expected arguments [dev api], got %v", result.Arguments)
+	}
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// suggestionMaxDistance is the edit distance a suggestion must stay below
+	suggestionMaxDistance = 3
+	// suggestionMinSimilarity is the share of matching characters a
+	// suggestion must exceed
+	suggestionMinSimilarity = 0.4
+)
+
+// editDistance returns the Damerau-Levenshtein distance between a and b,
+// counting adjacent transpositions as a single edit
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// suggestSimilar returns the candidates closest to word, as Commander.js does:
+// only candidates within the distance and similarity thresholds are kept, and
+// all candidates sharing the best distance are returned sorted. Long option
+// candidates are compared without their leading dashes.
+func suggestSimilar(word string, candidates []string) []string {
+	searchingOptions := strings.HasPrefix(word, "--")
+	if searchingOptions {
+		word = word[2:]
+	}
+
+	var similar []string
+	bestDistance := suggestionMaxDistance
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		name := candidate
+		if searchingOptions {
+			name = strings.TrimPrefix(name, "--")
+		}
+		// No single character guesses
+		if len([]rune(name)) <= 1 {
+			continue
+		}
+
+		distance := editDistance(word, name)
+		length := max(len([]rune(word)), len([]rune(name)))
+		similarity := float64(length-distance) / float64(length)
+		if similarity <= suggestionMinSimilarity {
+			continue
+		}
+		if distance < bestDistance {
+			bestDistance = distance
+			similar = []string{candidate}
+		} else if distance == bestDistance {
+			similar = append(similar, candidate)
+		}
+	}
+
+	slices.Sort(similar)
+	return similar
+}
+
+// formatSuggestion renders similar candidates as a "did you mean" hint
+func formatSuggestion(similar []string) string {
+	switch len(similar) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("Did you mean '%s'?", similar[0])
+	default:
+		quoted := make([]string, len(similar))
+		for i, s := range similar {
+			quoted[i] = "'" + s + "'"
+		}
+		return fmt.Sprintf("Did you mean one of %s?", strings.Join(quoted, ", "))
+	}
+}
+
+// suggestionCommandNames returns the names and aliases of the visible
+// subcommands, plus the help command when one is active
+func (c *Command) suggestionCommandNames() []string {
+	var names []string
+	for _, sub := range c.Subcommands {
+		if sub.Hidden {
+			continue
+		}
+		names = append(names, sub.Name)
+		names = append(names, sub.Aliases...)
+	}
+	if helpCmd := c.GetHelpCommand(); helpCmd != nil && !helpCmd.Hidden {
+		names = append(names, helpCmd.Name)
+	}
+	return names
+}
+
+// suggestOption returns a "did you mean" hint for an unknown long option.
+// Parent options are candidates when the parser resolves them, which is the
+// case under positional options.
+func (c *Command) suggestOption(flag string, includeParents bool) string {
+	if !strings.HasPrefix(flag, "--") {
+		return ""
+	}
+	flag, _, _ = strings.Cut(flag, "=")
+
+	var candidates []string
+	for _, opt := range c.CreateHelp().VisibleGlobalOptions(c) {
+		candidates = append(candidates, opt.longFlags()...)
+	}
+	for cmd := c; cmd != nil; cmd = cmd.Parent {
+		for _, opt := range cmd.CreateHelp().VisibleOptions(cmd) {
+			candidates = append(candidates, opt.longFlags()...)
+		}
+		if !includeParents {
+			break
+		}
+	}
+	return formatSuggestion(suggestSimilar(flag, candidates))
+}
+
+// suggestChoice returns a "did you mean" hint for a value outside choices
+func suggestChoice(value string, choices []string) string {
+	return formatSuggestion(suggestSimilar(value, choices))
+}
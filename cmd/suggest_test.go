@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"build", "build", 0},
+		{"biuld", "build", 1},
+		{"buidl", "build", 1},
+		{"bild", "build", 1},
+		{"deploy", "delpoy", 1},
+		{"", "test", 4},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.expected {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestSuggestSimilar(t *testing.T) {
+	candidates := []string{"build", "deploy", "destroy", "test", "text", "b"}
+
+	tests := []struct {
+		word     string
+		expected []string
+	}{
+		{"biuld", []string{"build"}},
+		{"detsroy", []string{"destroy"}},
+		{"xyz", nil},
+		{"a", nil},
+		{"--verbos", []string{"--verbose"}},
+		{"--colr", []string{"--color"}},
+		{"tezt", []string{"test", "text"}},
+	}
+
+	candidates = append(candidates, "--verbose", "--color", "--no-color", "--version")
+	for _, tt := range tests {
+		if got := suggestSimilar(tt.word, candidates); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("suggestSimilar(%q) = %v, want %v", tt.word, got, tt.expected)
+		}
+	}
+}
+
+func TestUnknownCommandSuggestion(t *testing.T) {
+	root := NewCommand("app")
+	root.ConfigureOutput(&OutputConfiguration{WriteErr: func(string) {}})
+	root.AddSubcommand(NewCommand("install").AddAlias("add"))
+	root.AddSubcommand(NewCommand("uninstall"))
+	secret := NewCommand("secret")
+	secret.Hidden = true
+	root.AddSubcommand(secret)
+
+	err := root.Execute([]string{"instal"})
+	var unknownErr *UnknownCommandError
+	if !errors.As(err, &unknownErr) {
+		t.Fatalf("Expected UnknownCommandError, got %v", err)
+	}
+	if unknownErr.Suggestion != "Did you mean 'install'?" {
+		t.Errorf("Unexpected suggestion %q", unknownErr.Suggestion)
+	}
+	if !strings.HasSuffix(unknownErr.Message, "\nDid you mean 'install'?") {
+		t.Errorf("Expected suggestion in message, got %q", unknownErr.Message)
+	}
+
+	if suggestion := root.GenerateSuggestion("secrte"); strings.Contains(suggestion, "secret") {
+		t.Errorf("Hidden commands should not be suggested, got %q", suggestion)
+	}
+
+	root.ShowSuggestionAfterError = false
+	err = root.Execute([]string{"instal"})
+	if !errors.As(err, &unknownErr) || unknownErr.Suggestion != "" {
+		t.Errorf("Expected no suggestion when disabled, got %v", err)
+	}
+}
+
+func TestUnknownOptionSuggestion(t *testing.T) {
+	root := NewCommand("app")
+	root.AddOption(NewBooleanOption("--no-color", "disable colors"))
+	deploy := NewCommand("deploy")
+	deploy.AddOption(NewOption("--region <name>", "region"))
+	root.AddSubcommand(deploy)
+
+	_, err := NewParser().ParseCommand(root, []string{"deploy", "--regoin", "eu"})
+	var unknownErr *UnknownOptionError
+	if !errors.As(err, &unknownErr) {
+		t.Fatalf("Expected UnknownOptionError, got %v", err)
+	}
+	if unknownErr.Suggestion != "Did you mean '--region'?" {
+		t.Errorf("Unexpected suggestion %q", unknownErr.Suggestion)
+	}
+
+	// Parent options are resolved, and so suggested, under positional options
+	parser := NewParser()
+	parser.EnablePositionalOptions = true
+	_, err = parser.ParseCommand(root, []string{"deploy", "--no-colour"})
+	if !errors.As(err, &unknownErr) || unknownErr.Suggestion != "Did you mean '--no-color'?" {
+		t.Errorf("Expected parent option suggestion, got %v", err)
+	}
+}
+
+func TestChoiceSuggestion(t *testing.T) {
+	root := NewCommand("app")
+	root.AddArgument(NewArgument("<env>", "environment").SetChoices([]string{"staging", "production"}))
+
+	_, err := NewParser().ParseCommand(root, []string{"prodution"})
+	if err == nil || !strings.Contains(err.Error(), "Did you mean 'production'?") {
+		t.Errorf("Expected choice suggestion, got %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValueParser is a parser usable with both Option.SetParser and
+// Argument.SetParser
+type ValueParser = func(value string, previous any) (any, error)
+
+// ValueType pairs a parser with the name help shows for its values, for
+// example "duration" to show an option without a value placeholder as
+// "--timeout <duration>", and optionally how shell completion suggests them.
+// Attach one with Option.SetValueType or Argument.SetValueType.
+type ValueType struct {
+	Name      string
+	Parser    ValueParser
+	Completer *Completer
+}
+
+// Built-in value types for the parsers below
+var (
+	DurationType = ValueType{Name: "duration", Parser: DurationParser}
+	TimeType     = ValueType{Name: "time", Parser: TimeParser}
+	ByteSizeType = ValueType{Name: "size", Parser: ByteSizeParser}
+	IPType       = ValueType{Name: "ip", Parser: IPParser}
+	CIDRType     = ValueType{Name: "cidr", Parser: CIDRParser}
+	URLType      = ValueType{Name: "url", Parser: URLParser}
+	KeyValueType = ValueType{Name: "key=value", Parser: KeyValueParser}
+	ListType     = ValueType{Name: "list", Parser: ListParser}
+	RegexpType   = ValueType{Name: "regexp", Parser: RegexpParser}
+	FileType     = ValueType{Name: "file", Parser: FileParser, Completer: FileCompleter()}
+	PathType     = ValueType{Name: "path", Parser: PathArgumentParser, Completer: FileCompleter()}
+)
+
+// EnumType returns a "choice" value type parsing with EnumParser
+func EnumType(values ...string) ValueType {
+	return ValueType{Name: "choice", Parser: EnumParser(values...)}
+}
+
+// IntRangeType returns an "int" value type parsing with IntRangeParser
+func IntRangeType(minValue, maxValue int) ValueType {
+	return ValueType{Name: "int", Parser: IntRangeParser(minValue, maxValue)}
+}
+
+// DurationParser parses a time.Duration such as "1h30m"
+func DurationParser(value string, previous any) (any, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration %q (expected a value like 30s, 5m or 1h30m)", value)
+	}
+	return d, nil
+}
+
+// TimeParser parses an RFC 3339 timestamp into a time.Time
+func TimeParser(value string, previous any) (any, error) {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q (expected RFC 3339, e.g. 2006-01-02T15:04:05Z)", value)
+	}
+	return t, nil
+}
+
+// byteSizeUnits maps lowercase unit suffixes to their size in bytes
+var byteSizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"kib": 1 << 10,
+	"m":   1e6,
+	"mb":  1e6,
+	"mib": 1 << 20,
+	"g":   1e9,
+	"gb":  1e9,
+	"gib": 1 << 30,
+	"t":   1e12,
+	"tb":  1e12,
+	"tib": 1 << 40,
+	"p":   1e15,
+	"pb":  1e15,
+	"pib": 1 << 50,
+}
+
+// ByteSizeParser parses a size such as "512", "10MB" or "1.5GiB" into an
+// int64 number of bytes. Decimal units (KB, MB, ...) are powers of 1000 and
+// binary units (KiB, MiB, ...) powers of 1024.
+func ByteSizeParser(value string, previous any) (any, error) {
+	trimmed := strings.TrimSpace(value)
+	split := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if split < 0 {
+		split = len(trimmed)
+	}
+
+	number, unit := trimmed[:split], strings.ToLower(strings.TrimSpace(trimmed[split:]))
+	multiplier, ok := byteSizeUnits[unit]
+	n, err := strconv.ParseFloat(number, 64)
+	if !ok || err != nil {
+		return nil, fmt.Errorf("invalid size %q (expected a value like 512, 10MB or 1.5GiB)", value)
+	}
+
+	size := n * multiplier
+	if size != math.Trunc(size) || size > math.MaxInt64 {
+		return nil, fmt.Errorf("invalid size %q: not a whole number of bytes that fits in int64", value)
+	}
+	return int64(size), nil
+}
+
+// IPParser parses an IPv4 or IPv6 address into a netip.Addr
+func IPParser(value string, previous any) (any, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP address %q", value)
+	}
+	return addr, nil
+}
+
+// CIDRParser parses a network prefix such as "10.0.0.0/8" into a netip.Prefix
+func CIDRParser(value string, previous any) (any, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %q (expected a value like 10.0.0.0/8)", value)
+	}
+	return prefix, nil
+}
+
+// URLParser parses an absolute URL into a *url.URL
+func URLParser(value string, previous any) (any, error) {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil || !u.IsAbs() {
+		return nil, fmt.Errorf("invalid URL %q (expected an absolute URL like https://example.com)", value)
+	}
+	return u, nil
+}
+
+// KeyValueParser parses "key=value" pairs into a map[string]string. Repeating
+// the option adds to the map, so --label a=b --label c=d yields both labels.
+func KeyValueParser(value string, previous any) (any, error) {
+	key, val, found := strings.Cut(value, "=")
+	key = strings.TrimSpace(key)
+	if !found || key == "" {
+		return nil, fmt.Errorf("invalid key=value pair %q", value)
+	}
+
+	result := make(map[string]string)
+	if existing, ok := previous.(map[string]string); ok {
+		maps.Copy(result, existing)
+	}
+	result[key] = val
+	return result, nil
+}
+
+// ListParser parses a comma-separated list into a []string, dropping empty
+// items. Repeating the option appends to the list.
+func ListParser(value string, previous any) (any, error) {
+	var result []string
+	if existing, ok := previous.([]string); ok {
+		result = append(result, existing...)
+	}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+// RegexpParser compiles a regular expression into a *regexp.Regexp
+func RegexpParser(value string, previous any) (any, error) {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %v", value, err)
+	}
+	return re, nil
+}
+
+// FileParser opens an existing file for reading and returns the *os.File.
+// The file belongs to the caller of a successful parse, usually the action,
+// which must close it. When parsing fails the parser closes the files it
+// opened.
+func FileParser(value string, previous any) (any, error) {
+	info, err := os.Stat(value)
+	if err != nil {
+		return nil, fmt.Errorf("file does not exist: %s", value)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("path is a directory, not a file: %s", value)
+	}
+
+	file, err := os.Open(value)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file %s: %v", value, err)
+	}
+	return file, nil
+}
+
+// EnumParser returns a parser accepting one of values, matched without
+// regard to case. The value is returned as spelled in values.
+func EnumParser(values ...string) ValueParser {
+	return enumParser(values).parse
+}
+
+// enumParser holds the values accepted by EnumParser
+type enumParser []string
+
+func (values enumParser) parse(value string, previous any) (any, error) {
+	for _, allowed := range values {
+		if strings.EqualFold(value, allowed) {
+			return allowed, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid choice '%s', expected one of: %s", value, strings.Join(values, ", "))
+}
+
+// IntRangeParser returns a parser accepting integers between minValue and
+// maxValue inclusive
+func IntRangeParser(minValue, maxValue int) ValueParser {
+	return intRangeParser{minValue, maxValue}.parse
+}
+
+// intRangeParser holds the bounds accepted by IntRangeParser
+type intRangeParser struct {
+	min, max int
+}
+
+func (r intRangeParser) parse(value string, previous any) (any, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid integer: %s", value)
+	}
+	if n < r.min || n > r.max {
+		return nil, fmt.Errorf("value %d is out of range [%d, %d]", n, r.min, r.max)
+	}
+	return n, nil
+}
+
+// closeParsedFiles closes the files opened during a parse that failed, since
+// the caller never receives them. Files given as defaults stay open.
+func (p *Parser) closeParsedFiles() {
+	defaults := make(map[*os.File]bool)
+	var opened []*os.File
+	for _, result := range p.results {
+		for _, option := range result.Command.Options {
+			for _, file := range filesIn(option.Default) {
+				defaults[file] = true
+			}
+		}
+		for _, argument := range result.Command.Arguments {
+			for _, file := range filesIn(argument.Default) {
+				defaults[file] = true
+			}
+		}
+		for _, value := range result.Options {
+			opened = append(opened, filesIn(value)...)
+		}
+		for _, value := range result.Arguments {
+			opened = append(opened, filesIn(value)...)
+		}
+	}
+
+	for _, file := range opened {
+		if !defaults[file] {
+			_ = file.Close()
+		}
+	}
+}
+
+// filesIn returns the files held by a parsed value, a file or a list of them
+func filesIn(value any) []*os.File {
+	switch v := value.(type) {
+	case *os.File:
+		return []*os.File{v}
+	case []any:
+		var files []*os.File
+		for _, item := range v {
+			files = append(files, filesIn(item)...)
+		}
+		return files
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValueParsers(t *testing.T) {
+	tests := []struct {
+		name     string
+		parser   ValueParser
+		input    string
+		expected any
+	}{
+		{"duration", DurationParser, "1h30m", 90 * time.Minute},
+		{"time", TimeParser, "2024-03-01T12:00:00Z", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"bytes", ByteSizeParser, "512", int64(512)},
+		{"decimal size", ByteSizeParser, "10MB", int64(10_000_000)},
+		{"binary size", ByteSizeParser, "1.5 GiB", int64(1536 << 20)},
+		{"lowercase size", ByteSizeParser, "10mib", int64(10 << 20)},
+		{"ipv4", IPParser, "192.168.0.1", netip.MustParseAddr("192.168.0.1")},
+		{"ipv6", IPParser, "::1", netip.MustParseAddr("::1")},
+		{"cidr", CIDRParser, "10.0.0.0/8", netip.MustParsePrefix("10.0.0.0/8")},
+		{"list", ListParser, "a, b,,c", []string{"a", "b", "c"}},
+		{"enum", EnumParser("Debug", "Info"), "INFO", "Info"},
+		{"range", IntRangeParser(1, 10), "10", 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parser(tt.input, nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %#v, got %#v", tt.expected, got)
+			}
+		})
+	}
+
+	if u, err := URLParser("https://example.com/path", nil); err != nil || u.(interface{ Hostname() string }).Hostname() != "example.com" {
+		t.Errorf("URLParser = %v, %v", u, err)
+	}
+	if re, err := RegexpParser("^a+$", nil); err != nil || !re.(interface{ MatchString(string) bool }).MatchString("aaa") {
+		t.Errorf("RegexpParser = %v, %v", re, err)
+	}
+}
+
+func TestValueParserErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser ValueParser
+		input  string
+	}{
+		{"duration", DurationParser, "soon"},
+		{"time", TimeParser, "2024-03-01"},
+		{"size unit", ByteSizeParser, "10XB"},
+		{"fractional bytes", ByteSizeParser, "1.5"},
+		{"ip", IPParser, "300.1.1.1"},
+		{"cidr", CIDRParser, "10.0.0.0"},
+		{"relative url", URLParser, "example.com"},
+		{"key without value", KeyValueParser, "label"},
+		{"regexp", RegexpParser, "("},
+		{"missing file", FileParser, filepath.Join(t.TempDir(), "missing")},
+		{"directory", FileParser, t.TempDir()},
+		{"enum", EnumParser("debug", "info"), "trace"},
+		{"range", IntRangeParser(1, 10), "11"},
+		{"range integer", IntRangeParser(1, 10), "five"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.parser(tt.input, nil); err == nil {
+				t.Errorf("Expected error for %q", tt.input)
+			}
+		})
+	}
+}
+
+func TestFileParserOpensFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := FileParser(path, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	file := value.(*os.File)
+	defer file.Close()
+
+	data := make([]byte, 5)
+	if n, err := file.Read(data); err != nil || string(data[:n]) != "hello" {
+		t.Errorf("Expected to read file contents, got %q, %v", data[:n], err)
+	}
+}
+
+func TestValueParsersWithCommand(t *testing.T) {
+	root := NewCommand("deploy")
+	root.AddOption(NewOption("-l, --label <kv>", "labels").SetParser(KeyValueParser))
+	root.AddOption(NewOption("--tags <list>", "tags").SetParser(ListParser))
+	root.AddOption(NewOption("-t, --timeout", "deploy timeout").SetValueType(DurationType))
+	root.AddOption(NewOption("--level", "log level").SetValueType(EnumType("debug", "info")))
+	root.AddArgument(NewArgument("<replicas>", "replica count").SetValueType(IntRangeType(1, 5)))
+
+	result, err := NewParser().ParseCommand(root,
+		[]string{"--label", "a=b", "-l", "c=d=e", "--tags", "x,y", "--tags", "z", "-t", "5m", "--level", "DEBUG", "3"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if labels := result.Options["label"]; !reflect.DeepEqual(labels, map[string]string{"a": "b", "c": "d=e"}) {
+		t.Errorf("Unexpected labels: %#v", labels)
+	}
+	if tags := result.Options["tags"]; !reflect.DeepEqual(tags, []string{"x", "y", "z"}) {
+		t.Errorf("Unexpected tags: %#v", tags)
+	}
+	if timeout, err := result.GetDuration("timeout"); err != nil || timeout != 5*time.Minute {
+		t.Errorf("Unexpected timeout: %v, %v", timeout, err)
+	}
+	if level := result.Options["level"]; level != "debug" {
+		t.Errorf("Unexpected level: %#v", level)
+	}
+	if replicas := result.Arguments[0]; replicas != 3 {
+		t.Errorf("Unexpected replicas: %#v", replicas)
+	}
+
+	if _, err := NewParser().ParseCommand(root, []string{"9"}); err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Errorf("Expected range error, got %v", err)
+	}
+
+	help := root.GenerateHelp()
+	for _, expected := range []string{"-t, --timeout <duration>", "--level <choice>", "-l, --label <kv>", "(type: int)"} {
+		if !strings.Contains(help, expected) {
+			t.Errorf("Expected help to contain %q, got:\n%s", expected, help)
+		}
+	}
+}
+
+func TestValueTypes(t *testing.T) {
+	root := NewCommand("serve")
+	root.AddOption(NewOption("--port", "port").SetValueType(ValueType{Name: "port", Parser: IntRangeParser(1, 65535)}))
+	root.AddOption(NewOption("--workers", "worker count").SetValueType(IntRangeType(1, 64)))
+	root.AddOption(NewOption("--input", "input file").SetValueType(FileType))
+	root.AddArgument(NewArgument("[root]", "document root").SetValueType(PathType))
+
+	// Parsers from the same factory keep their own names
+	help := root.GenerateHelp()
+	for _, expected := range []string{"--port <port>", "--workers <int>", "--input <file>", "(type: path)"} {
+		if !strings.Contains(help, expected) {
+			t.Errorf("Expected help to contain %q, got:\n%s", expected, help)
+		}
+	}
+
+	if _, directive := root.Complete([]string{"--input", ""}); directive != CompletionDirectiveFiles {
+		t.Errorf("Expected the file type to complete file names, got directive %d", directive)
+	}
+	if _, directive := root.Complete([]string{""}); directive != CompletionDirectiveFiles {
+		t.Errorf("Expected the path type to complete file names, got directive %d", directive)
+	}
+}
+
+func TestFileParserClosedOnParseError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var opened *os.File
+	root := NewCommand("app")
+	root.AddOption(NewOption("--input", "input file").SetParser(func(value string, previous any) (any, error) {
+		file, err := FileParser(value, previous)
+		opened, _ = file.(*os.File)
+		return file, err
+	}))
+	root.AddOption(NewOption("--stdin", "input stream").SetDefault(os.Stdin))
+	root.AddOption(NewOption("--workers", "worker count").SetValueType(IntRangeType(1, 64)))
+
+	if _, err := NewParser().ParseCommand(root, []string{"--input", path, "--workers", "100"}); err == nil {
+		t.Fatal("Expected range error")
+	}
+	if opened == nil {
+		t.Fatal("Expected the file to be opened")
+	}
+	if _, err := opened.Stat(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Expected the file to be closed after the failed parse, got %v", err)
+	}
+	if _, err := os.Stdin.Stat(); err != nil {
+		t.Errorf("Default files should stay open, got %v", err)
+	}
+}
@@ -867,9 +867,6 @@ Expecting one of '${allowedValues.join("', '")}'`);
                 throw new Error(`${event} hook failed: ${error.message}`);
             }
         }
-        
-        // Also execute hooks in WASM if available
-        await this._executeHooksInWASM(event, actionCommand);
     }
 
     exitOverride(fn) {
@@ -1430,6 +1427,16 @@ Expecting one of '${allowedValues.join("', '")}'`);
 
             if (result.error) {
                 console.warn('Failed to add option to WASM:', result.error);
+            } else if (option.parseArg) {
+                const parserResult = wasmInterface.setOptionParser(
+                    this._wasmCommandId,
+                    option.name(),
+                    option.parseArg
+                );
+
+                if (parserResult.error) {
+                    console.warn('Failed to set option parser in WASM:', parserResult.error);
+                }
             }
         } catch (error) {
             console.warn('Error adding option to WASM:', error.message);
@@ -1452,6 +1459,16 @@ Expecting one of '${allowedValues.join("', '")}'`);
 
             if (result.error) {
                 console.warn('Failed to add argument to WASM:', result.error);
+            } else if (argument.parseArg) {
+                const parserResult = wasmInterface.setArgumentParser(
+                    this._wasmCommandId,
+                    argument.name(),
+                    argument.parseArg
+                );
+
+                if (parserResult.error) {
+                    console.warn('Failed to set argument parser in WASM:', parserResult.error);
+                }
             }
         } catch (error) {
             console.warn('Error adding argument to WASM:', error.message);
@@ -2442,7 +2459,7 @@ Expecting one of '${allowedValues.join("', '")}'`);
 
         try {
             const wasmInterface = wasmLoader.getInterface();
-            const result = wasmInterface.addHook(this._wasmCommandId, event);
+            const result = wasmInterface.addHook(this._wasmCommandId, event, listener);
 
             if (result.error) {
                 console.warn('Failed to add hook in WASM:', result.error);
@@ -2469,27 +2486,6 @@ Expecting one of '${allowedValues.join("', '")}'`);
         }
     }
 
-    async _executeHooksInWASM(event, actionCommand) {
-        if (!this._wasmCommandId || !wasmLoader.isWASMLoaded()) {
-            return;
-        }
-
-        try {
-            const wasmInterface = wasmLoader.getInterface();
-            const result = wasmInterface.executeHooks(
-                this._wasmCommandId, 
-                event, 
-                actionCommand._wasmCommandId
-            );
-
-            if (result.error) {
-                console.warn('Failed to execute hooks in WASM:', result.error);
-            }
-        } catch (error) {
-            console.warn('Error executing hooks in WASM:', error.message);
-        }
-    }
-
     async _setAsyncActionInWASM(fn) {
         if (!this._wasmCommandId || !wasmLoader.isWASMLoaded()) {
             return;
@@ -2497,7 +2493,7 @@ Expecting one of '${allowedValues.join("', '")}'`);
 
         try {
             const wasmInterface = wasmLoader.getInterface();
-            const result = wasmInterface.setAsyncAction(this._wasmCommandId);
+            const result = wasmInterface.setAsyncAction(this._wasmCommandId, fn);
 
             if (result.error) {
                 console.warn('Failed to set async action in WASM:', result.error);
@@ -53,6 +53,8 @@ global.gocommander = {
   createCommand: jest.fn().mockReturnValue({ id: 'test-id', name: 'test' }),
   addOption: jest.fn().mockReturnValue({ success: true }),
   addArgument: jest.fn().mockReturnValue({ success: true }),
+  setOptionParser: jest.fn().mockReturnValue({ success: true }),
+  setArgumentParser: jest.fn().mockReturnValue({ success: true }),
   parseArguments: jest.fn().mockReturnValue({
     command: 'test',
     options: {},
@@ -62,7 +64,7 @@ global.gocommander = {
   setParsingConfig: jest.fn().mockReturnValue({ success: true }),
   configureOutput: jest.fn().mockReturnValue({ success: true }),
   addHook: jest.fn().mockReturnValue({ success: true }),
-  executeHooks: jest.fn().mockReturnValue({ success: true })
+  executeHooks: jest.fn().mockResolvedValue({ success: true })
 };
 
 // Suppress console warnings in tests